@@ -0,0 +1,75 @@
+package holdings
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrEditionMismatch         = errors.New("edition mismatch")
+	ErrPublicationDateMismatch = errors.New("publication date mismatch")
+)
+
+// Book is a license for a monograph, e.g. an e-book in a package. A book
+// is identified by its ISBNs, so coverage only checks, whether a record
+// refers to the licensed edition.
+type Book struct {
+	// Edition is the licensed edition, e.g. 2.
+	Edition string
+	// Date is the publication date, often just a year.
+	Date string
+	// Title, Comment and URL are informational and taken from the holding
+	// file.
+	Title   string
+	Comment string
+	URL     string
+	// Access is the access type, if the holding file tells.
+	Access AccessType
+}
+
+// Covers returns an error, if the record names another edition or was
+// published in another year. Missing values on either side are fine, since
+// the ISBN already identifies the book.
+func (b Book) Covers(s Signature) error {
+	if b.Edition != "" && s.Edition != "" && findInt(b.Edition) != findInt(s.Edition) {
+		return ErrEditionMismatch
+	}
+	if b.Date != "" && s.Date != "" && yearOf(b.Date) != yearOf(s.Date) {
+		return ErrPublicationDateMismatch
+	}
+	return nil
+}
+
+// TimeRestricted always returns nil, books have no moving walls.
+func (b Book) TimeRestricted(t time.Time) error {
+	return nil
+}
+
+// booksOf returns the distinct books among a list of licenses.
+func booksOf(licenses []License) []Book {
+	var books []Book
+	for _, l := range licenses {
+		if b, ok := l.(Book); ok && !containsBook(books, b) {
+			books = append(books, b)
+		}
+	}
+	return books
+}
+
+// containsBook returns true, if b is in books.
+func containsBook(books []Book, b Book) bool {
+	for _, x := range books {
+		if x == b {
+			return true
+		}
+	}
+	return false
+}
+
+// yearOf returns the year of a date.
+func yearOf(s string) string {
+	if len(s) > 4 {
+		return s[:4]
+	}
+	return s
+}
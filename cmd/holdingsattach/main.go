@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/formats"
+)
+
+// source splits an argument of the form [label=]path. The label defaults to
+// the file name without extension.
+func source(arg string) (label, path string) {
+	if i := strings.Index(arg, "="); i > 0 {
+		return arg[:i], arg[i+1:]
+	}
+	base := filepath.Base(arg)
+	return strings.TrimSuffix(base, filepath.Ext(base)), arg
+}
+
+// fields names the fields of a record.
+type fields struct {
+	ISSN, EISSN, Date, Volume, Issue, Labels string
+}
+
+// values returns the strings of a field, which may be a string, a number or
+// a list of those.
+func values(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var vs []interface{}
+	if err := json.Unmarshal(raw, &vs); err != nil {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil
+		}
+		vs = []interface{}{v}
+	}
+	var result []string
+	for _, v := range vs {
+		switch t := v.(type) {
+		case string:
+			if t = strings.TrimSpace(t); t != "" {
+				result = append(result, t)
+			}
+		case float64:
+			result = append(result, fmt.Sprintf("%v", t))
+		}
+	}
+	return result
+}
+
+// first returns the first value of a field or the empty string.
+func first(raw json.RawMessage) string {
+	if vs := values(raw); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+// attach checks a single record and adds the labels of all granting
+// sources. Existing labels are kept.
+func attach(doc map[string]json.RawMessage, tenants holdings.Tenants, f fields) error {
+	record := holdings.Record{
+		Identifiers: append(values(doc[f.ISSN]), values(doc[f.EISSN])...),
+		Signature: holdings.Signature{
+			Date:   first(doc[f.Date]),
+			Volume: first(doc[f.Volume]),
+			Issue:  first(doc[f.Issue]),
+		},
+	}
+	allowed := tenants.Allowed(record)
+	if len(allowed) == 0 {
+		return nil
+	}
+	labels := values(doc[f.Labels])
+	seen := make(map[string]bool)
+	for _, l := range labels {
+		seen[l] = true
+	}
+	for _, isil := range allowed {
+		if !seen[string(isil)] {
+			labels = append(labels, string(isil))
+		}
+	}
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return err
+	}
+	doc[f.Labels] = b
+	return nil
+}
+
+func main() {
+	format := flag.String("format", "kbart", "holding file format: "+strings.Join(formats.Names(), ", "))
+	linkage := flag.String("linkage", "", "TSV file with bibliographic control numbers and ISSNs for marcholdings")
+	tenantsPath := flag.String("tenants", "", "tenants directory or config file, labels are ISILs")
+	input := flag.String("i", "", "input file with JSON lines, default stdin")
+	var f fields
+	flag.StringVar(&f.ISSN, "issn-field", "rft.issn", "field with print ISSNs")
+	flag.StringVar(&f.EISSN, "eissn-field", "rft.eissn", "field with online ISSNs")
+	flag.StringVar(&f.Date, "date-field", "rft.date", "field with the publication date")
+	flag.StringVar(&f.Volume, "volume-field", "rft.volume", "field with the volume")
+	flag.StringVar(&f.Issue, "issue-field", "rft.issue", "field with the issue")
+	flag.StringVar(&f.Labels, "labels-field", "x.labels", "field to add labels to")
+	verbose := flag.Bool("verbose", false, "be verbose")
+
+	flag.Parse()
+
+	if *linkage != "" {
+		if err := formats.RegisterLinkage(*linkage); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flag.NArg() == 0 && *tenantsPath == "" {
+		log.Fatal("usage: holdingsattach [OPTIONS] [LABEL=]FILE ... < records.ldj")
+	}
+
+	policy := holdings.ErrorPolicy{Hook: func(err *holdings.RecordError) holdings.ErrorAction {
+		if *verbose {
+			log.Printf("skipping: %s", err)
+		}
+		return holdings.SkipSilently
+	}}
+
+	tenants := make(holdings.Tenants)
+	if *tenantsPath != "" {
+		var err error
+		if tenants, err = formats.LoadTenants(*tenantsPath, policy); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, arg := range flag.Args() {
+		label, path := source(arg)
+		entries, err := formats.ReadFile(path, *format, policy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tenants[holdings.ISIL(label)] = entries
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "" {
+		file, err := os.Open(*input)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	var n, attached int
+	for {
+		var doc map[string]json.RawMessage
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			log.Fatal(err)
+		}
+		n++
+		before := string(doc[f.Labels])
+		if err := attach(doc, tenants, f); err != nil {
+			log.Fatal(err)
+		}
+		if string(doc[f.Labels]) != before {
+			attached++
+		}
+		if err := enc.Encode(doc); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *verbose {
+		log.Printf("%d of %d record(s) got labels", attached, n)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/formats"
+)
+
+func main() {
+	var r io.Reader
+
+	format := flag.String("format", "kbart", "holding file format: "+strings.Join(formats.Names(), ", "))
+	linkage := flag.String("linkage", "", "TSV file with bibliographic control numbers and ISSNs for marcholdings")
+	report := flag.String("report", "text", "report format: text, json or tsv")
+	rules := flag.Bool("rules", false, "show rule catalog of the format and exit")
+
+	flag.Parse()
+
+	if *linkage != "" {
+		if err := formats.RegisterLinkage(*linkage); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	f, err := formats.Lookup(*format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *rules {
+		b, err := json.MarshalIndent(f.Rules, "", "    ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(b))
+		os.Exit(0)
+	}
+
+	if flag.NArg() == 0 {
+		r = bufio.NewReader(os.Stdin)
+	} else {
+		file, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	rep, err := f.NewReader(r, holdings.ErrorPolicy{}).Validate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := rep.Write(os.Stdout, *report); err != nil {
+		log.Fatal(err)
+	}
+	if rep.Errors() > 0 {
+		os.Exit(1)
+	}
+}
@@ -9,9 +9,9 @@ import (
 	"time"
 
 	"github.com/miku/holdings"
-	"github.com/miku/holdings/google"
-	"github.com/miku/holdings/kbart"
-	"github.com/miku/holdings/ovid"
+	"github.com/miku/holdings/csvholdings"
+	"github.com/miku/holdings/formats"
+	"github.com/miku/holdings/history"
 )
 
 var layouts = []string{
@@ -19,54 +19,94 @@ var layouts = []string{
 	"2006-01-02",
 }
 
+// loadHistory reads a title history graph.
+func loadHistory(filename, format string) (*history.Graph, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	switch format {
+	case "marc":
+		return history.ReadMARC(file)
+	case "kbart":
+		return history.ReadKBART(file)
+	default:
+		return nil, fmt.Errorf("unknown history format: %s", format)
+	}
+}
+
+// loadConfig reads a csv mapping file.
+func loadConfig(filename string) (csvholdings.Config, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return csvholdings.Config{}, err
+	}
+	defer file.Close()
+	return csvholdings.ReadConfig(file)
+}
+
+// granted describes a positive decision.
+func granted(access holdings.AccessType) string {
+	switch access {
+	case holdings.FreeAccess:
+		return "No restrictions, free access."
+	case holdings.LicensedAccess:
+		return "No restrictions, licensed access."
+	}
+	return "No restrictions."
+}
+
 func main() {
+	config := flag.String("config", "", "mapping file, enables -format csv")
 	date := flag.String("date", "", "record date")
+	doajFile := flag.String("doaj", "", "DOAJ journal CSV, adds free access coverage")
 	filename := flag.String("file", "", "holding file")
-	format := flag.String("format", "kbart", "holding file format: kbart, ovid or google")
+	format := flag.String("format", "kbart", "holding file format: "+strings.Join(formats.Names(), ", "))
+	linkage := flag.String("linkage", "", "TSV file with bibliographic control numbers and ISSNs for marcholdings")
+	historyFile := flag.String("history", "", "title history file, to follow title changes")
+	historyFormat := flag.String("history-format", "marc", "title history file format: marc or kbart")
+	isil := flag.String("isil", "", "comma separated ISILs to check, default all tenants")
 	issn := flag.String("issn", "", "record issn")
 	issue := flag.String("issue", "", "record issue")
+	tenantsPath := flag.String("tenants", "", "tenants directory or config file, instead of -file")
 	volume := flag.String("volume", "", "record volume")
 	verbose := flag.Bool("verbose", false, "be verbose")
 
 	flag.Parse()
 
-	if *issn == "" {
-		log.Fatal("-issn is required")
+	if *linkage != "" {
+		if err := formats.RegisterLinkage(*linkage); err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	if *filename == "" {
-		log.Fatal("a holding -file is required")
+	if *issn == "" {
+		log.Fatal("-issn is required")
 	}
 
-	if *date == "" {
-		log.Fatal("-date is required")
+	if *filename == "" && *tenantsPath == "" {
+		log.Fatal("a holding -file or -tenants is required")
 	}
 
-	file, err := os.Open(*filename)
-	if err != nil {
-		log.Fatal(err)
+	if *isil != "" && *tenantsPath == "" {
+		log.Fatal("-isil requires -tenants")
 	}
 
-	var hfile holdings.File
-
-	switch *format {
-	case "kbart":
-		hfile = kbart.NewReader(file)
-	case "ovid":
-		hfile = ovid.NewReader(file)
-	case "google":
-		hfile = google.NewReader(file)
-	default:
-		log.Fatal("unknown format")
-
+	if *date == "" {
+		log.Fatal("-date is required")
 	}
 
-	entries, err := hfile.ReadAll()
-	if err != nil {
-		log.Fatal(err)
+	if *config != "" {
+		c, err := loadConfig(*config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		formats.Register(formats.CSV("csv", c))
 	}
 
 	var t time.Time
+	var err error
 
 	for _, layout := range layouts {
 		t, err = time.Parse(layout, *date)
@@ -81,7 +121,98 @@ func main() {
 
 	s := holdings.Signature{Date: *date, Volume: *volume, Issue: *issue}
 
-	licenses := entries.Licenses(*issn)
+	// skip broken records, but keep count
+	var skipped int
+	policy := holdings.ErrorPolicy{Hook: func(err *holdings.RecordError) holdings.ErrorAction {
+		skipped++
+		if *verbose {
+			log.Printf("skipping: %s", err)
+		}
+		return holdings.SkipSilently
+	}}
+
+	var g *history.Graph
+	if *historyFile != "" {
+		if g, err = loadHistory(*historyFile, *historyFormat); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var free holdings.Entries
+	if *doajFile != "" {
+		if free, err = formats.ReadFile(*doajFile, "doaj", policy); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *tenantsPath != "" {
+		tenants, err := formats.LoadTenants(*tenantsPath, policy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *verbose && skipped > 0 {
+			log.Printf("%d record(s) skipped", skipped)
+		}
+		isils := tenants.ISILs()
+		if *isil != "" {
+			isils = nil
+			for _, v := range strings.Split(*isil, ",") {
+				isils = append(isils, holdings.ISIL(strings.TrimSpace(v)))
+			}
+		}
+		for k, h := range tenants {
+			if free != nil {
+				h = holdings.Sources{h, free}
+			}
+			if g != nil {
+				h = history.Holdings{Holdings: h, Graph: g}
+			}
+			tenants[k] = h
+		}
+		decisions := tenants.Check(holdings.Record{Identifiers: []string{*issn}, Signature: s, Time: t})
+		for _, k := range isils {
+			d, ok := decisions[k]
+			switch {
+			case !ok:
+				fmt.Printf("%s\tNO\tUnknown tenant.\n", k)
+			case d.OK:
+				fmt.Printf("%s\tOK\t%s\n", k, granted(d.Access))
+			case d.Err == holdings.ErrMovingWall:
+				fmt.Printf("%s\tNO\tMoving wall applies.\n", k)
+			default:
+				fmt.Printf("%s\tNO\tNot covered: %s\n", k, d.Err)
+			}
+		}
+		return
+	}
+
+	file, err := os.Open(*filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hfile, err := formats.NewReader(*format, file, policy)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	entries, err := hfile.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *verbose && skipped > 0 {
+		log.Printf("%d record(s) skipped", skipped)
+	}
+
+	var h holdings.Holdings = entries
+	if free != nil {
+		h = holdings.Sources{entries, free}
+	}
+	if g != nil {
+		h = history.Holdings{Holdings: h, Graph: g}
+	}
+
+	licenses := h.Licenses(*issn)
 
 	for i, license := range licenses {
 		if *verbose {
@@ -91,7 +222,7 @@ func main() {
 		cov, wall := license.Covers(s), license.TimeRestricted(t)
 
 		if cov == nil && wall == nil {
-			fmt.Printf("%d\tOK\tNo restrictions.\n", i)
+			fmt.Printf("%d\tOK\t%s\n", i, granted(holdings.AccessOf(license)))
 		}
 		if cov != nil {
 			fmt.Printf("%d\tNO\tNot covered: %s\n", i, cov)
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/formats"
+	"github.com/miku/holdings/kbart"
+)
+
+// readFile reads a holding file. A broken record fails, unless skip is set,
+// since its coverage would show up as added or removed. Online identifiers
+// of KBART files are recorded in online.
+func readFile(filename, format string, skip, verbose bool, online map[string]bool) (holdings.Entries, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var skipped int
+	policy := holdings.ErrorPolicy{Action: holdings.FailFast}
+	if skip {
+		policy.Hook = func(err *holdings.RecordError) holdings.ErrorAction {
+			skipped++
+			if verbose {
+				log.Printf("%s: skipping: %s", filename, err)
+			}
+			return holdings.SkipSilently
+		}
+	}
+
+	hfile, err := formats.NewReader(format, file, policy)
+	if err != nil {
+		return nil, err
+	}
+	if kr, ok := hfile.(*kbart.Reader); ok {
+		kr.Online = online
+	}
+	entries, err := hfile.ReadAll()
+	if skipped > 0 {
+		log.Printf("%s: %d broken record(s) skipped, their coverage may show up as added or removed", filename, skipped)
+	}
+	return entries, err
+}
+
+func main() {
+	format := flag.String("format", "kbart", "holding file format: "+strings.Join(formats.Names(), ", "))
+	linkage := flag.String("linkage", "", "TSV file with bibliographic control numbers and ISSNs for marcholdings")
+	newFormat := flag.String("new-format", "", "format of the newer file, if it differs from -format")
+	output := flag.String("o", "text", "output format: text, json or kbart")
+	skip := flag.Bool("skip", false, "skip broken records and report their number, instead of failing")
+	verbose := flag.Bool("verbose", false, "be verbose")
+
+	flag.Parse()
+
+	if *linkage != "" {
+		if err := formats.RegisterLinkage(*linkage); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flag.NArg() != 2 {
+		log.Fatal("usage: holdingsdiff [OPTIONS] OLD NEW")
+	}
+	if *newFormat == "" {
+		*newFormat = *format
+	}
+
+	online := make(map[string]bool)
+	a, err := readFile(flag.Arg(0), *format, *skip, *verbose, online)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := readFile(flag.Arg(1), *newFormat, *skip, *verbose, online)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	changes := holdings.Diff(a, b)
+	if *output == "kbart" {
+		err = changes.WriteKBARTRoles(os.Stdout, func(id string) bool { return online[id] })
+	} else {
+		err = changes.Write(os.Stdout, *output)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/formats"
+	"github.com/miku/holdings/query"
+)
+
+func main() {
+	format := flag.String("format", "kbart", "holding file format: "+strings.Join(formats.Names(), ", "))
+	linkage := flag.String("linkage", "", "TSV file with bibliographic control numbers and ISSNs for marcholdings")
+	output := flag.String("o", "solr", "output: solr (fq string) or es (bool query)")
+	fields := query.DefaultFields
+	flag.StringVar(&fields.ISSN, "issn-field", fields.ISSN, "index field with ISSNs")
+	flag.StringVar(&fields.ISBN, "isbn-field", fields.ISBN, "index field with ISBNs")
+	flag.StringVar(&fields.Year, "year-field", fields.Year, "index field with the publication year")
+	flag.StringVar(&fields.Date, "date-field", fields.Date, "index date field for moving walls, default whole years")
+	verbose := flag.Bool("verbose", false, "be verbose")
+
+	flag.Parse()
+
+	if *linkage != "" {
+		if err := formats.RegisterLinkage(*linkage); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: holdingsfilter [OPTIONS] FILE ...")
+	}
+
+	policy := holdings.ErrorPolicy{Hook: func(err *holdings.RecordError) holdings.ErrorAction {
+		if *verbose {
+			log.Printf("skipping: %s", err)
+		}
+		return holdings.SkipSilently
+	}}
+
+	entries := make(holdings.Entries)
+	for _, filename := range flag.Args() {
+		e, err := formats.ReadFile(filename, *format, policy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for id, licenses := range e {
+			entries[id] = append(entries[id], licenses...)
+		}
+	}
+
+	b := query.NewBuilder()
+	b.Fields = fields
+	groups := b.Groups(entries)
+	if *verbose {
+		log.Printf("%d identifier(s) in %d group(s)", len(entries), len(groups))
+	}
+
+	var err error
+	switch *output {
+	case "solr":
+		err = query.WriteSolr(os.Stdout, groups, fields)
+	case "es":
+		err = query.WriteElasticsearch(os.Stdout, groups, fields)
+	default:
+		log.Fatalf("unknown output: %s", *output)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
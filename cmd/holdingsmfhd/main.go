@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/formats"
+	"github.com/miku/holdings/marcholdings"
+)
+
+// source splits an argument of the form [name=]path. The name defaults to
+// the file name without extension.
+func source(arg string) (name, path string) {
+	if i := strings.Index(arg, "="); i > 0 {
+		return arg[:i], arg[i+1:]
+	}
+	base := filepath.Base(arg)
+	return strings.TrimSuffix(base, filepath.Ext(base)), arg
+}
+
+func main() {
+	format := flag.String("format", "kbart", "holding file format: "+strings.Join(formats.Names(), ", "))
+	linkage := flag.String("linkage", "", "TSV file with bibliographic control numbers and ISSNs for marcholdings")
+	output := flag.String("o", "xml", "output encoding: xml or iso")
+	locations := flag.String("locations", "", "JSON file with 852 location data by source name")
+	verbose := flag.Bool("verbose", false, "be verbose")
+
+	flag.Parse()
+
+	if *linkage != "" {
+		if err := formats.RegisterLinkage(*linkage); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: holdingsmfhd [OPTIONS] [NAME=]FILE ...")
+	}
+
+	locs := make(marcholdings.Locations)
+	if *locations != "" {
+		file, err := os.Open(*locations)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if locs, err = marcholdings.ReadLocations(file); err != nil {
+			log.Fatal(err)
+		}
+		file.Close()
+	}
+
+	var w *marcholdings.Writer
+	switch *output {
+	case "xml":
+		w = marcholdings.NewXMLWriter(os.Stdout)
+	case "iso":
+		w = marcholdings.NewWriter(os.Stdout)
+	default:
+		log.Fatalf("unknown output encoding: %s", *output)
+	}
+
+	policy := holdings.ErrorPolicy{Hook: func(err *holdings.RecordError) holdings.ErrorAction {
+		if *verbose {
+			log.Printf("skipping: %s", err)
+		}
+		return holdings.SkipSilently
+	}}
+
+	for _, arg := range flag.Args() {
+		name, path := source(arg)
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r, err := formats.NewReader(*format, file, policy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		entries, err := r.ReadAll()
+		if err != nil {
+			log.Fatal(err)
+		}
+		file.Close()
+
+		w.Location = locs.Lookup(name)
+		w.Prefix = name + "-"
+		if err := w.WriteAll(entries); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
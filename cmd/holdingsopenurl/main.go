@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/formats"
+	"github.com/miku/holdings/links"
+	"github.com/miku/holdings/openurl"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8000", "address to listen on")
+	format := flag.String("format", "kbart", "holding file format: "+strings.Join(formats.Names(), ", "))
+	linkage := flag.String("linkage", "", "TSV file with bibliographic control numbers and ISSNs for marcholdings")
+	redirect := flag.Bool("redirect", false, "redirect to the best link, if access is granted")
+	templatesFile := flag.String("templates", "", "JSON file with deep link templates")
+	verbose := flag.Bool("verbose", false, "be verbose")
+
+	flag.Parse()
+
+	if *linkage != "" {
+		if err := formats.RegisterLinkage(*linkage); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if flag.NArg() == 0 {
+		log.Fatal("usage: holdingsopenurl [OPTIONS] FILE ...")
+	}
+
+	policy := holdings.ErrorPolicy{Hook: func(err *holdings.RecordError) holdings.ErrorAction {
+		if *verbose {
+			log.Printf("skipping: %s", err)
+		}
+		return holdings.SkipSilently
+	}}
+
+	entries := make(holdings.Entries)
+	for _, filename := range flag.Args() {
+		e, err := formats.ReadFile(filename, *format, policy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for id, licenses := range e {
+			entries[id] = append(entries[id], licenses...)
+		}
+	}
+
+	var templates links.Templates
+	if *templatesFile != "" {
+		file, err := os.Open(*templatesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if templates, err = links.ReadTemplates(file); err != nil {
+			log.Fatal(err)
+		}
+		file.Close()
+	}
+
+	http.Handle("/", openurl.Handler{Holdings: entries, Templates: templates, Redirect: *redirect})
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
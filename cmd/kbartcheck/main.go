@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -12,49 +13,65 @@ import (
 	"github.com/miku/holdings/kbart"
 )
 
+// hasHeader returns true, if the input starts with a KBART header row.
+func hasHeader(r *bufio.Reader) bool {
+	b, _ := r.Peek(len("\ufeffpublication_title"))
+	b = bytes.TrimPrefix(b, []byte("\ufeff"))
+	return bytes.HasPrefix(b, []byte("publication_title"))
+}
+
 func main() {
 	var r io.Reader
 
-	skipHeader := flag.Bool("skip", false, "skip header row")
-	verbose := flag.Bool("verbose", false, "report line numbers for errors")
+	skipHeader := flag.Bool("skip", false, "file has a header row, default: detect")
+	noHeader := flag.Bool("noheader", false, "file has no header row, assume KBART Phase II column order")
+	flag.Bool("verbose", false, "deprecated and ignored, problems are always reported with line numbers")
+	format := flag.String("format", "text", "report format: text, json or tsv")
+	rules := flag.Bool("rules", false, "show rule catalog and exit")
 
 	flag.Parse()
 
+	if *rules {
+		b, err := json.MarshalIndent(kbart.Rules, "", "    ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(b))
+		os.Exit(0)
+	}
+
 	if flag.NArg() == 0 {
-		r = bufio.NewReader(os.Stdin)
+		r = os.Stdin
 	} else {
 		file, err := os.Open(flag.Arg(0))
 		if err != nil {
 			log.Fatal(err)
 		}
+		defer file.Close()
 		r = file
 	}
 
-	kr := kbart.NewReader(r)
-	kr.SkipFirstRow = *skipHeader
-
-	stats := make(map[string]int)
-	var i int
-
-	for {
-		_, _, err := kr.Read()
-		if err == io.EOF {
-			break
-		}
-		i++
-		if err != nil {
-			if *verbose {
-				log.Printf("line %d: %s", i, err)
-			}
-			stats[err.Error()]++
-		}
+	br := bufio.NewReader(r)
+	kr := kbart.NewReader(br)
+	switch {
+	case *skipHeader:
+		kr.SkipFirstRow = true
+	case *noHeader:
+		kr.SkipFirstRow = false
+	default:
+		kr.SkipFirstRow = hasHeader(br)
 	}
 
-	stats["records"] = i
-
-	b, err := json.Marshal(stats)
+	report, err := kr.Validate()
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Println(string(b))
+
+	if err := report.Write(os.Stdout, *format); err != nil {
+		log.Fatal(err)
+	}
+
+	if report.Errors() > 0 {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,443 @@
+package holdings
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Coverage intervals are entries, where an empty Begin or End signature
+// stands for an open end. Signatures are ordered by date, volume and issue;
+// a field only takes part in a comparison, if it is set on both sides.
+// Intervals are only compared with intervals of the same kind, so a range
+// of volumes never absorbs a range of years.
+
+// kind tells, in which unit an interval is measured.
+type kind int
+
+const (
+	dateKind kind = iota
+	volumeKind
+	issueKind
+	openKind
+)
+
+// kindOf returns the kind of an interval. An interval with a date is
+// measured in dates, even if it also names volumes; so is an open entry,
+// which is not restricted by date.
+func kindOf(e Entry) kind {
+	switch {
+	case e.Begin.Date != "" || e.End.Date != "" || e.Open:
+		return dateKind
+	case e.Begin.Volume != "" || e.End.Volume != "":
+		return volumeKind
+	case e.Begin.Issue != "" || e.End.Issue != "":
+		return issueKind
+	}
+	return openKind
+}
+
+// sameKind returns true, if two intervals can be compared.
+func sameKind(x, y Entry) bool {
+	return kindOf(x) == kindOf(y)
+}
+
+// isOpen returns true, if the signature does not restrict anything.
+func isOpen(s Signature) bool {
+	return s.Date == "" && s.Volume == "" && s.Issue == ""
+}
+
+// compareSignatures orders two signatures.
+func compareSignatures(a, b Signature) int {
+	if a.Date != "" && b.Date != "" {
+		switch {
+		case a.Date < b.Date:
+			return -1
+		case a.Date > b.Date:
+			return 1
+		}
+	}
+	if a.Volume != "" && b.Volume != "" {
+		if c := compareInts(a.VolumeInt(), b.VolumeInt()); c != 0 {
+			return c
+		}
+	}
+	if a.Issue != "" && b.Issue != "" {
+		if c := compareInts(a.IssueInt(), b.IssueInt()); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareBegin orders two begin signatures, an open begin comes first.
+func compareBegin(a, b Signature) int {
+	switch ao, bo := isOpen(a), isOpen(b); {
+	case ao && bo:
+		return 0
+	case ao:
+		return -1
+	case bo:
+		return 1
+	}
+	return compareSignatures(a, b)
+}
+
+// compareEnd orders two end signatures, an open end comes last.
+func compareEnd(a, b Signature) int {
+	switch ao, bo := isOpen(a), isOpen(b); {
+	case ao && bo:
+		return 0
+	case ao:
+		return 1
+	case bo:
+		return -1
+	}
+	return compareSignatures(a, b)
+}
+
+// beginBeforeEnd returns true, if begin is not later than end.
+func beginBeforeEnd(begin, end Signature) bool {
+	if isOpen(begin) || isOpen(end) {
+		return true
+	}
+	return compareSignatures(begin, end) <= 0
+}
+
+// overlaps returns true, if two intervals of the same kind share at least
+// one point.
+func overlaps(x, y Entry) bool {
+	return sameKind(x, y) && beginBeforeEnd(x.Begin, y.End) && beginBeforeEnd(y.Begin, x.End)
+}
+
+// adjacent returns true, if begin directly follows end, so two intervals
+// can be joined without a gap. Dates are compared with the precision of
+// the less precise date, volumes are consecutive, if they differ by one.
+// Volumes are only looked at, if the dates do not differ. A more precise
+// date only reaches into the next period, if it is the last unit of its
+// period, e.g. 2003-12 is followed by 2004, but 2003-06 is not.
+func adjacent(end, begin Signature) bool {
+	if isOpen(end) || isOpen(begin) {
+		return false
+	}
+	bothVolumes := end.Volume != "" && begin.Volume != ""
+	if end.Date != "" && begin.Date != "" {
+		n := len(end.Date)
+		if len(begin.Date) < n {
+			n = len(begin.Date)
+		}
+		e, b := Signature{Date: end.Date[:n]}, Signature{Date: begin.Date[:n]}
+		if compareSignatures(b, e) != 0 {
+			if len(end.Date) > n && shiftDate(end.Date, 1)[:n] == e.Date {
+				return false
+			}
+			if len(begin.Date) > n && shiftDate(begin.Date, -1)[:n] == b.Date {
+				return false
+			}
+			return compareSignatures(b, Successor(e)) <= 0
+		}
+		if !bothVolumes {
+			return true
+		}
+	}
+	if bothVolumes {
+		return begin.VolumeInt() <= end.VolumeInt()+1 && begin.IssueInt() <= 1
+	}
+	return false
+}
+
+// shiftDate moves a date of the form YYYY, YYYY-MM or YYYY-MM-DD by delta
+// units of its precision. Unparseable dates are returned unchanged.
+func shiftDate(s string, delta int) string {
+	switch len(s) {
+	case 4:
+		if t, err := time.Parse("2006", s); err == nil {
+			return t.AddDate(delta, 0, 0).Format("2006")
+		}
+	case 7:
+		if t, err := time.Parse("2006-01", s); err == nil {
+			return t.AddDate(0, delta, 0).Format("2006-01")
+		}
+	case 10:
+		if t, err := time.Parse("2006-01-02", s); err == nil {
+			return t.AddDate(0, 0, delta).Format("2006-01-02")
+		}
+	}
+	return s
+}
+
+// padDate extends a date of the form YYYY or YYYY-MM to the first month or
+// day of its period, until it has length n.
+func padDate(s string, n int) string {
+	for len(s) < n && (len(s) == 4 || len(s) == 7) {
+		s += "-01"
+	}
+	return s
+}
+
+// Predecessor returns the signature just before s, at the finest level of
+// detail available.
+func Predecessor(s Signature) Signature {
+	switch {
+	case s.Issue != "" && s.IssueInt() > 1:
+		return Signature{Date: s.Date, Volume: s.Volume, Issue: strconv.Itoa(s.IssueInt() - 1)}
+	case s.Volume != "" && s.VolumeInt() > 1:
+		return Signature{Date: s.Date, Volume: strconv.Itoa(s.VolumeInt() - 1)}
+	default:
+		return Signature{Date: shiftDate(s.Date, -1)}
+	}
+}
+
+// Successor returns the signature just after s, at the finest level of
+// detail available.
+func Successor(s Signature) Signature {
+	switch {
+	case s.Issue != "":
+		return Signature{Date: s.Date, Volume: s.Volume, Issue: strconv.Itoa(s.IssueInt() + 1)}
+	case s.Volume != "":
+		return Signature{Date: s.Date, Volume: strconv.Itoa(s.VolumeInt() + 1)}
+	default:
+		return Signature{Date: shiftDate(s.Date, 1)}
+	}
+}
+
+// wall identifies a moving wall.
+type wall struct {
+	embargo         time.Duration
+	disallowEarlier bool
+}
+
+func wallOf(e Entry) wall {
+	if e.Embargo == 0 {
+		return wall{}
+	}
+	return wall{e.Embargo, e.EmbargoDisallowEarlier}
+}
+
+// wallGrants returns true, if the moving wall of x allows at least the
+// content the moving wall of y allows.
+func wallGrants(x, y Entry) bool {
+	switch {
+	case x.Embargo == 0:
+		return true
+	case y.Embargo == 0:
+		return false
+	case x.EmbargoDisallowEarlier != y.EmbargoDisallowEarlier:
+		return false
+	case x.EmbargoDisallowEarlier:
+		// only the most recent content is available, a longer period is
+		// more generous
+		return x.Embargo <= y.Embargo
+	default:
+		// the most recent content is not available, a shorter period is
+		// more generous
+		return x.Embargo >= y.Embargo
+	}
+}
+
+// sortEntries orders intervals by kind, begin and end.
+func sortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if ki, kj := kindOf(entries[i]), kindOf(entries[j]); ki != kj {
+			return ki < kj
+		}
+		if c := compareBegin(entries[i].Begin, entries[j].Begin); c != 0 {
+			return c < 0
+		}
+		return compareEnd(entries[i].End, entries[j].End) < 0
+	})
+}
+
+// group identifies intervals, that may be joined.
+type group struct {
+	wall wall
+	kind kind
+}
+
+// normalize joins overlapping and adjacent intervals with the same moving
+// wall and kind and returns them sorted. Other intervals are kept apart. The
+// first interval of a joined range provides the metadata.
+func normalize(entries []Entry) []Entry {
+	groups := make(map[group][]Entry)
+	var keys []group
+	for _, e := range entries {
+		k := group{wallOf(e), kindOf(e)}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], e)
+	}
+	var result []Entry
+	for _, k := range keys {
+		group := groups[k]
+		sortEntries(group)
+		current := group[0]
+		for _, next := range group[1:] {
+			if overlaps(current, next) || adjacent(current.End, next.Begin) {
+				if compareEnd(next.End, current.End) > 0 {
+					current.End = next.End
+				}
+				continue
+			}
+			result = append(result, current)
+			current = next
+		}
+		result = append(result, current)
+	}
+	sortEntries(result)
+	return result
+}
+
+// subtractEntry returns the parts of x, that are not covered by y.
+func subtractEntry(x, y Entry) []Entry {
+	if !overlaps(x, y) {
+		return []Entry{x}
+	}
+	var result []Entry
+	if !isOpen(y.Begin) && compareBegin(x.Begin, y.Begin) < 0 {
+		left := x
+		left.End = Predecessor(y.Begin)
+		if beginBeforeEnd(left.Begin, left.End) {
+			result = append(result, left)
+		}
+	}
+	if !isOpen(y.End) && compareEnd(y.End, x.End) < 0 {
+		right := x
+		right.Begin = Successor(y.End)
+		if beginBeforeEnd(right.Begin, right.End) {
+			result = append(result, right)
+		}
+	}
+	return result
+}
+
+// intersectEntry returns the common part of x and y and whether there is
+// one. The result gets the stricter moving wall; if the walls cannot be
+// compared, the wall of x is kept.
+func intersectEntry(x, y Entry) (Entry, bool) {
+	if !overlaps(x, y) {
+		return Entry{}, false
+	}
+	result := x
+	if compareBegin(y.Begin, x.Begin) > 0 {
+		result.Begin = y.Begin
+	}
+	if compareEnd(y.End, x.End) < 0 {
+		result.End = y.End
+	}
+	if wallGrants(x, y) {
+		result.Embargo, result.EmbargoDisallowEarlier = y.Embargo, y.EmbargoDisallowEarlier
+	}
+	return result, true
+}
+
+// subtractAll removes all intervals in ys from the intervals in xs. An
+// interval of ys only counts, if it is of the same kind and its moving wall
+// grants at least as much as the moving wall of the interval it is
+// subtracted from.
+func subtractAll(xs, ys []Entry) []Entry {
+	var result []Entry
+	for _, x := range xs {
+		pieces := []Entry{x}
+		for _, y := range ys {
+			if !sameKind(x, y) || !wallGrants(y, x) {
+				continue
+			}
+			var next []Entry
+			for _, p := range pieces {
+				next = append(next, subtractEntry(p, y)...)
+			}
+			pieces = next
+		}
+		result = append(result, pieces...)
+	}
+	return result
+}
+
+// entriesOf returns the licenses, that are entries. Books have no coverage
+// interval, see booksOf, other license implementations cannot be inspected
+// and are left out.
+func entriesOf(licenses []License) []Entry {
+	var entries []Entry
+	for _, l := range licenses {
+		if e, ok := l.(Entry); ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// licensesOf turns entries and books into licenses.
+func licensesOf(entries []Entry, books []Book) []License {
+	var licenses []License
+	for _, e := range entries {
+		licenses = append(licenses, e)
+	}
+	for _, b := range books {
+		licenses = append(licenses, b)
+	}
+	return licenses
+}
+
+// Normalize returns the coverage of a list of licenses as a sorted list of
+// disjoint intervals. Overlapping and adjacent intervals are joined. Where
+// intervals with different moving walls overlap, the more generous wall
+// wins. Only licenses of type Entry have coverage intervals, books and other
+// licenses are left out.
+func Normalize(licenses []License) []Entry {
+	return mergeEntries(entriesOf(licenses))
+}
+
+// Coverage returns the normalized coverage for an identifier.
+func (e Entries) Coverage(id string) []Entry {
+	return Normalize(e.Licenses(id))
+}
+
+// Gaps returns the missing intervals between coverage intervals of the same
+// kind, regardless of moving walls. If both sides of a gap carry a date, the
+// gap is reported in whole dates, e.g. the gap between 1995-2003 and
+// 2005-2010 is 2004, the gap between 1995-2003-06 and 2004-2010 is
+// 2003-07-2003-12.
+func Gaps(entries []Entry) []Entry {
+	var static []Entry
+	for _, e := range entries {
+		static = append(static, Entry{Begin: e.Begin, End: e.End, Open: e.Open})
+	}
+	static = normalize(static)
+
+	var gaps []Entry
+	for i := 1; i < len(static); i++ {
+		end, begin := static[i-1].End, static[i].Begin
+		if !sameKind(static[i-1], static[i]) || isOpen(end) || isOpen(begin) {
+			continue
+		}
+		gap := Entry{Begin: Successor(end), End: Predecessor(begin)}
+		if end.Date != "" && begin.Date != "" {
+			n := len(end.Date)
+			if len(begin.Date) > n {
+				n = len(begin.Date)
+			}
+			coarse := Entry{
+				Begin: Signature{Date: padDate(shiftDate(end.Date, 1), n)},
+				End:   Signature{Date: shiftDate(padDate(begin.Date, n), -1)},
+			}
+			if beginBeforeEnd(coarse.Begin, coarse.End) {
+				gap = coarse
+			}
+		}
+		if beginBeforeEnd(gap.Begin, gap.End) {
+			gaps = append(gaps, gap)
+		}
+	}
+	return gaps
+}
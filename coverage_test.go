@@ -0,0 +1,87 @@
+package holdings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAndGaps(t *testing.T) {
+	var cases = []struct {
+		about    string
+		licenses []License
+		coverage []Entry
+		gaps     []Entry
+	}{
+		{
+			about:    "empty",
+			licenses: nil,
+			coverage: nil,
+			gaps:     nil,
+		},
+		{
+			about:    "covered 1995-2003, 2005-present; gap 2004",
+			licenses: []License{span("2005", ""), span("1995", "2000"), span("1998", "2003")},
+			coverage: []Entry{span("1995", "2003"), span("2005", "")},
+			gaps:     []Entry{span("2004", "2004")},
+		},
+		{
+			about:    "adjacent years leave no gap",
+			licenses: []License{span("1995", "2003"), span("2004", "2010")},
+			coverage: []Entry{span("1995", "2010")},
+			gaps:     nil,
+		},
+		{
+			about: "gap in volumes within the same year",
+			licenses: []License{
+				Entry{Begin: Signature{Date: "2000", Volume: "1"}, End: Signature{Date: "2000", Volume: "2"}},
+				Entry{Begin: Signature{Date: "2000", Volume: "5"}, End: Signature{Date: "2000", Volume: "6"}},
+			},
+			coverage: []Entry{
+				{Begin: Signature{Date: "2000", Volume: "1"}, End: Signature{Date: "2000", Volume: "2"}},
+				{Begin: Signature{Date: "2000", Volume: "5"}, End: Signature{Date: "2000", Volume: "6"}},
+			},
+			gaps: []Entry{{Begin: Signature{Date: "2000", Volume: "3"}, End: Signature{Date: "2000", Volume: "4"}}},
+		},
+		{
+			about:    "the last month of a year is followed by the next year",
+			licenses: []License{span("1995", "2003-12"), span("2004", "")},
+			coverage: []Entry{span("1995", "")},
+			gaps:     nil,
+		},
+		{
+			about:    "a month within a year leaves the rest of the year",
+			licenses: []License{span("1995", "2003-06"), span("2004", "")},
+			coverage: []Entry{span("1995", "2003-06"), span("2004", "")},
+			gaps:     []Entry{span("2003-07", "2003-12")},
+		},
+		{
+			about: "volumes and years are kept apart",
+			licenses: []License{
+				Entry{Begin: Signature{Volume: "1"}, End: Signature{Volume: "10"}},
+				span("1990", "1995"),
+				span("1980", "1985"),
+			},
+			coverage: []Entry{
+				span("1980", "1985"),
+				span("1990", "1995"),
+				{Begin: Signature{Volume: "1"}, End: Signature{Volume: "10"}},
+			},
+			gaps: []Entry{span("1986", "1989")},
+		},
+		{
+			about:    "open coverage absorbs dated ranges",
+			licenses: []License{span("1990", "1995"), Entry{Open: true}},
+			coverage: []Entry{{Open: true}},
+			gaps:     nil,
+		},
+	}
+	for _, c := range cases {
+		coverage := Normalize(c.licenses)
+		if !reflect.DeepEqual(coverage, c.coverage) {
+			t.Errorf("%s: Normalize got %+v, want %+v", c.about, coverage, c.coverage)
+		}
+		if gaps := Gaps(coverage); !reflect.DeepEqual(gaps, c.gaps) {
+			t.Errorf("%s: Gaps got %+v, want %+v", c.about, gaps, c.gaps)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+// Package csvholdings reads spreadsheets, that are almost KBART. The layout
+// of a vendor file is described by a Config, usually kept as a JSON mapping
+// file, so a new vendor needs a new mapping instead of a new reader:
+//
+//	{
+//	  "delimiter": ";",
+//	  "header": true,
+//	  "columns": {
+//	    "title": "Titel",
+//	    "identifiers": ["ISSN", "eISSN"],
+//	    "begin": {"date": "Start", "volume": "Jg. von"},
+//	    "end": {"date": "Ende", "volume": "Jg. bis"},
+//	    "embargo": "Sperrfrist",
+//	    "comment": "Bemerkung"
+//	  },
+//	  "date_layouts": ["02.01.2006", "2006"],
+//	  "embargo": {"syntax": "months"}
+//	}
+//
+// Columns are referenced by header name or by 1-based position. Mapping files
+// are JSON only, since the package sticks to the standard library; YAML
+// mappings need to be converted first.
+package csvholdings
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miku/holdings"
+)
+
+var (
+	ErrInvalidConfig      = errors.New("invalid config")
+	ErrUnknownColumn      = errors.New("unknown column")
+	ErrInvalidDate        = errors.New("invalid date")
+	ErrInvalidEmbargo     = errors.New("invalid embargo")
+	ErrMissingIdentifiers = errors.New("missing identifiers")
+)
+
+// Embargo syntaxes.
+const (
+	KBART  = "kbart"  // P1Y, R6M, P30D
+	Days   = "days"   // a number of days
+	Months = "months" // a number of months
+	Years  = "years"  // a number of years
+)
+
+// defaultLayouts are used, if a config lists no date layouts.
+var defaultLayouts = []string{"2006-01-02", "2006-01", "2006"}
+
+// Config describes the layout of a vendor file.
+type Config struct {
+	// Delimiter separates fields, e.g. "," or ";". Use "\t" or "tab" for
+	// tab separated files. Defaults to a comma.
+	Delimiter string `json:"delimiter"`
+	// Header tells, whether the first row names the columns.
+	Header bool `json:"header"`
+	// Columns maps entry fields to columns.
+	Columns Columns `json:"columns"`
+	// DateLayouts are tried in order to parse dates, in the notation of the
+	// time package. Defaults to YYYY-MM-DD, YYYY-MM and YYYY.
+	DateLayouts []string `json:"date_layouts"`
+	// Embargo describes the embargo syntax.
+	Embargo EmbargoSyntax `json:"embargo"`
+}
+
+// Columns maps entry fields to columns, given by header name or by 1-based
+// position. Empty columns are not read.
+type Columns struct {
+	Title       string          `json:"title"`
+	Identifiers []string        `json:"identifiers"`
+	Begin       SignatureColumn `json:"begin"`
+	End         SignatureColumn `json:"end"`
+	Embargo     string          `json:"embargo"`
+	Comment     string          `json:"comment"`
+	Status      string          `json:"status"`
+}
+
+// SignatureColumn maps the fields of a signature to columns.
+type SignatureColumn struct {
+	Date   string `json:"date"`
+	Volume string `json:"volume"`
+	Issue  string `json:"issue"`
+}
+
+// EmbargoSyntax describes, how embargoes are written. Syntax is one of
+// kbart, days, months or years and defaults to kbart. Alternatively, Pattern
+// is a regular expression with the named groups n and unit and an optional
+// group rolling; a non-empty rolling group means, only recent content is
+// available. Units maps the text of the unit group to d, m or y; by default
+// the first letter decides.
+type EmbargoSyntax struct {
+	Syntax  string            `json:"syntax"`
+	Pattern string            `json:"pattern"`
+	Units   map[string]string `json:"units"`
+
+	re *regexp.Regexp
+}
+
+// ReadConfig reads a JSON mapping file.
+func ReadConfig(r io.Reader) (Config, error) {
+	var c Config
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return c, err
+	}
+	return c, c.compile()
+}
+
+// compile checks the config and prepares the embargo pattern.
+func (c *Config) compile() error {
+	if len(c.Columns.Identifiers) == 0 {
+		return fmt.Errorf("%w: no identifier columns", ErrInvalidConfig)
+	}
+	if _, err := c.delimiter(); err != nil {
+		return err
+	}
+	e := &c.Embargo
+	if e.Pattern != "" && e.re == nil {
+		re, err := regexp.Compile(e.Pattern)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidConfig, err)
+		}
+		if re.SubexpIndex("n") < 0 {
+			return fmt.Errorf("%w: embargo pattern needs a group named n", ErrInvalidConfig)
+		}
+		e.re = re
+	}
+	switch e.Syntax {
+	case "", KBART, Days, Months, Years:
+	default:
+		return fmt.Errorf("%w: unknown embargo syntax %q", ErrInvalidConfig, e.Syntax)
+	}
+	return nil
+}
+
+// delimiter returns the field separator.
+func (c Config) delimiter() (rune, error) {
+	switch c.Delimiter {
+	case "":
+		return ',', nil
+	case "\t", `\t`, "tab":
+		return '\t', nil
+	}
+	if rs := []rune(c.Delimiter); len(rs) == 1 {
+		return rs[0], nil
+	}
+	return 0, fmt.Errorf("%w: delimiter must be a single character", ErrInvalidConfig)
+}
+
+// ParseDate parses a date with the configured layouts and returns it as
+// YYYY, YYYY-MM or YYYY-MM-DD, depending on the precision of the layout.
+func (c Config) ParseDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	layouts := c.DateLayouts
+	if len(layouts) == 0 {
+		layouts = defaultLayouts
+	}
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		switch {
+		case strings.Contains(layout, "02") || strings.Contains(layout, "_2"):
+			return t.Format("2006-01-02"), nil
+		case strings.Contains(layout, "01") || strings.Contains(layout, "Jan"):
+			return t.Format("2006-01"), nil
+		default:
+			return t.Format("2006"), nil
+		}
+	}
+	return "", ErrInvalidDate
+}
+
+// ParseEmbargo parses an embargo according to the configured syntax. Like
+// in the other formats, the duration is negative.
+func (c Config) ParseEmbargo(s string) (d time.Duration, disallowEarlier bool, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false, nil
+	}
+	e := c.Embargo
+	if e.re != nil {
+		ms := e.re.FindStringSubmatch(s)
+		if ms == nil {
+			return 0, false, ErrInvalidEmbargo
+		}
+		group := func(name string) string {
+			if i := e.re.SubexpIndex(name); i >= 0 {
+				return ms[i]
+			}
+			return ""
+		}
+		unit, ok := e.Units[group("unit")]
+		if !ok {
+			unit = strings.ToLower(group("unit"))
+		}
+		return embargo(group("n"), unit, group("rolling") != "")
+	}
+	switch e.Syntax {
+	case Days:
+		return embargo(s, "d", false)
+	case Months:
+		return embargo(s, "m", false)
+	case Years:
+		return embargo(s, "y", false)
+	}
+	if len(s) < 3 || (s[0] != 'P' && s[0] != 'R') {
+		return 0, false, ErrInvalidEmbargo
+	}
+	return embargo(s[1:len(s)-1], strings.ToLower(s[len(s)-1:]), s[0] == 'R')
+}
+
+// embargo converts a number and a unit, of which the first letter counts,
+// into a moving wall.
+func embargo(n, unit string, rolling bool) (time.Duration, bool, error) {
+	i, err := strconv.Atoi(strings.TrimSpace(n))
+	if err != nil || i < 0 {
+		return 0, false, ErrInvalidEmbargo
+	}
+	if unit == "" {
+		return 0, false, ErrInvalidEmbargo
+	}
+	switch unit[0] {
+	case 'd':
+		return time.Duration(-i) * holdings.Day, rolling, nil
+	case 'm':
+		return time.Duration(-i) * holdings.Month, rolling, nil
+	case 'y', 'j':
+		return time.Duration(-i) * holdings.Year, rolling, nil
+	}
+	return 0, false, ErrInvalidEmbargo
+}
+
+// Reader reads delimited files as described by a config. Rows, whose dates
+// or embargo the config cannot parse or which lack an identifier, go to
+// Policy, which skips them by default and keeps their errors for a
+// holdings.ParseError.
+type Reader struct {
+	r      *csv.Reader
+	config Config
+	index  map[string]int
+	record []string
+	err    error
+	Policy holdings.ErrorPolicy
+}
+
+// NewReader returns a reader for a config. An invalid config is reported by
+// the first call to Read.
+func NewReader(r io.Reader, c Config) *Reader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	err := c.compile()
+	if err == nil {
+		cr.Comma, _ = c.delimiter()
+	}
+	return &Reader{
+		r:      cr,
+		config: c,
+		err:    err,
+		Policy: holdings.ErrorPolicy{Action: holdings.SkipAndCollect},
+	}
+}
+
+// Row is a single row, with values looked up by the configured columns.
+// Identifiers, that contain an ISSN, are normalized to the form 1234-567X.
+type Row struct {
+	Title       string
+	Identifiers []string
+	Begin       holdings.Signature
+	End         holdings.Signature
+	Embargo     string
+	Comment     string
+	Status      string
+}
+
+// Read returns the next row. At the end of the input io.EOF is returned.
+// Unknown columns in the config result in ErrUnknownColumn.
+func (r *Reader) Read() (Row, error) {
+	var row Row
+	if r.err != nil {
+		return row, r.err
+	}
+	if r.index == nil {
+		if err := r.header(); err != nil {
+			r.err = err
+			return row, err
+		}
+	}
+	record, err := r.r.Read()
+	if err != nil {
+		return row, err
+	}
+	r.record = record
+	get := func(col string) string {
+		if i, ok := r.index[col]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	c := r.config.Columns
+	for _, col := range c.Identifiers {
+		v := get(col)
+		if issn, ok := holdings.NormalizeISSN(v); ok {
+			v = issn
+		}
+		if v != "" {
+			row.Identifiers = append(row.Identifiers, v)
+		}
+	}
+	row.Title = get(c.Title)
+	row.Begin = holdings.Signature{Date: get(c.Begin.Date), Volume: get(c.Begin.Volume), Issue: get(c.Begin.Issue)}
+	row.End = holdings.Signature{Date: get(c.End.Date), Volume: get(c.End.Volume), Issue: get(c.End.Issue)}
+	row.Embargo = get(c.Embargo)
+	row.Comment = get(c.Comment)
+	row.Status = get(c.Status)
+	return row, nil
+}
+
+// header reads the header row, if there is one, and resolves all configured
+// columns to positions.
+func (r *Reader) header() error {
+	names := make(map[string]int)
+	if r.config.Header {
+		record, err := r.r.Read()
+		if err != nil {
+			return err
+		}
+		for i, name := range record {
+			name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+			if _, ok := names[name]; !ok {
+				names[name] = i
+			}
+		}
+	}
+	c := r.config.Columns
+	cols := append([]string{c.Title, c.Embargo, c.Comment, c.Status,
+		c.Begin.Date, c.Begin.Volume, c.Begin.Issue,
+		c.End.Date, c.End.Volume, c.End.Issue}, c.Identifiers...)
+	r.index = make(map[string]int)
+	for _, col := range cols {
+		if col == "" {
+			continue
+		}
+		if i, ok := names[col]; ok {
+			r.index[col] = i
+			continue
+		}
+		if i, err := strconv.Atoi(col); err == nil && i > 0 {
+			r.index[col] = i - 1
+			continue
+		}
+		return fmt.Errorf("%w: %s", ErrUnknownColumn, col)
+	}
+	return nil
+}
+
+// Entry converts a row into an entry, parsing dates and embargo.
+func (r *Reader) Entry(row Row) (holdings.Entry, error) {
+	entry := holdings.Entry{
+		Begin:   row.Begin,
+		End:     row.End,
+		Title:   row.Title,
+		Comment: row.Comment,
+		Status:  row.Status,
+	}
+	var err error
+	if entry.Begin.Date, err = r.config.ParseDate(row.Begin.Date); err != nil {
+		return entry, err
+	}
+	if entry.End.Date, err = r.config.ParseDate(row.End.Date); err != nil {
+		return entry, err
+	}
+	entry.Embargo, entry.EmbargoDisallowEarlier, err = r.config.ParseEmbargo(row.Embargo)
+	return entry, err
+}
+
+// Line returns the line of the row read last.
+func (r *Reader) Line() int {
+	if r.record == nil {
+		return 0
+	}
+	line, _ := r.r.FieldPos(0)
+	return line
+}
+
+func (r *Reader) ReadAll() (holdings.Entries, error) {
+	entries := make(holdings.Entries)
+
+	perr := holdings.ParseError{}
+
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		entry, err := r.Entry(row)
+		if err == nil && len(row.Identifiers) == 0 {
+			err = ErrMissingIdentifiers
+		}
+		if err != nil {
+			rerr := &holdings.RecordError{
+				Line:    r.Line(),
+				Snippet: strings.Join(r.record, string(r.r.Comma)),
+				Err:     err,
+			}
+			if len(row.Identifiers) > 0 {
+				rerr.Record = row.Identifiers[0]
+			}
+			if err := r.Policy.Handle(&perr, rerr); err != nil {
+				return entries, err
+			}
+			continue
+		}
+		for _, id := range row.Identifiers {
+			entries[id] = append(entries[id], entry)
+		}
+	}
+	if len(perr.Errors) > 0 {
+		return entries, perr
+	}
+	return entries, nil
+}
@@ -0,0 +1,129 @@
+package csvholdings
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miku/holdings"
+)
+
+const config = `{
+  "delimiter": ";",
+  "header": true,
+  "columns": {
+    "title": "Titel",
+    "identifiers": ["ISSN", "eISSN"],
+    "begin": {"date": "Start", "volume": "Jg. von"},
+    "end": {"date": "Ende"},
+    "embargo": "Sperrfrist",
+    "comment": "Bemerkung"
+  },
+  "date_layouts": ["02.01.2006", "01/2006", "2006"],
+  "embargo": {"syntax": "months"}
+}`
+
+const sheet = "\ufeffTitel;ISSN;eISSN;Start;Jg. von;Ende;Sperrfrist;Bemerkung\n" +
+	"Journal of Things;0006-2499;;01.03.1995;10;2003;12;\n" +
+	"\"Annals; of Stuff\";;1613-4141;03/2001;;;;nur online\n" +
+	"Broken;1234-5679;;Frühjahr 2001;;;;\n" +
+	"Nobody;;;2001;;;;\n"
+
+func TestReadAll(t *testing.T) {
+	c, err := ReadConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := NewReader(strings.NewReader(sheet), c).ReadAll()
+	var perr holdings.ParseError
+	if !errors.As(err, &perr) || len(perr.Errors) != 2 || !errors.Is(err, ErrInvalidDate) || !errors.Is(err, ErrMissingIdentifiers) {
+		t.Fatalf("ReadAll: got %v, want an invalid date and missing identifiers", err)
+	}
+	var rerr *holdings.RecordError
+	if errors.As(err, &rerr); rerr.Line != 4 || rerr.Record != "1234-5679" {
+		t.Errorf("ReadAll: got error at line %d for %q, want line 4 for 1234-5679", rerr.Line, rerr.Record)
+	}
+	want := holdings.Entries{
+		"0006-2499": []holdings.License{holdings.Entry{
+			Begin:   holdings.Signature{Date: "1995-03-01", Volume: "10"},
+			End:     holdings.Signature{Date: "2003"},
+			Embargo: -12 * holdings.Month,
+			Title:   "Journal of Things",
+		}},
+		"1613-4141": []holdings.License{holdings.Entry{
+			Begin:   holdings.Signature{Date: "2001-03"},
+			Title:   "Annals; of Stuff",
+			Comment: "nur online",
+		}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ReadAll: got %+v, want %+v", entries, want)
+	}
+}
+
+func TestReadNormalizesISSN(t *testing.T) {
+	config := Config{Delimiter: ";", Columns: Columns{Identifiers: []string{"1", "2"}}}
+	row, err := NewReader(strings.NewReader("00062499;1613-414x (Online)\n"), config).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"0006-2499", "1613-414X"}; !reflect.DeepEqual(row.Identifiers, want) {
+		t.Errorf("Read: got %v, want %v", row.Identifiers, want)
+	}
+}
+
+func TestParseEmbargo(t *testing.T) {
+	var cases = []struct {
+		syntax  EmbargoSyntax
+		s       string
+		d       time.Duration
+		rolling bool
+		err     error
+	}{
+		{EmbargoSyntax{}, "R6M", -6 * holdings.Month, true, nil},
+		{EmbargoSyntax{}, "P1Y", -holdings.Year, false, nil},
+		{EmbargoSyntax{}, "6 months", 0, false, ErrInvalidEmbargo},
+		{EmbargoSyntax{Syntax: Days}, "30", -30 * holdings.Day, false, nil},
+		{
+			EmbargoSyntax{Pattern: `^(?P<rolling>nur )?(?:letzte )?(?P<n>\d+) (?P<unit>\w+)$`, Units: map[string]string{"Tage": "d"}},
+			"nur letzte 2 Jahre", -2 * holdings.Year, true, nil,
+		},
+		{
+			EmbargoSyntax{Pattern: `^(?P<rolling>nur )?(?:letzte )?(?P<n>\d+) (?P<unit>\w+)$`, Units: map[string]string{"Tage": "d"}},
+			"90 Tage", -90 * holdings.Day, false, nil,
+		},
+	}
+	for _, c := range cases {
+		config := Config{Columns: Columns{Identifiers: []string{"1"}}, Embargo: c.syntax}
+		if err := config.compile(); err != nil {
+			t.Fatal(err)
+		}
+		d, rolling, err := config.ParseEmbargo(c.s)
+		if d != c.d || rolling != c.rolling || err != c.err {
+			t.Errorf("ParseEmbargo(%q): got %v, %v, %v, want %v, %v, %v", c.s, d, rolling, err, c.d, c.rolling, c.err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Config{
+		Columns: Columns{Identifiers: []string{"1"}, Begin: SignatureColumn{Date: "2"}, End: SignatureColumn{Date: "3"}},
+	}
+	report, err := NewReader(strings.NewReader("0006-2499,2003,1995\n,2001,\n0006-2499,2001-03,2001\n"), c).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rules []string
+	for _, p := range report.Problems {
+		rules = append(rules, p.Rule)
+	}
+	if want := []string{RuleDateOrder, RuleIdentifier}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("Validate: got %v, want %v", rules, want)
+	}
+	report, err = NewReader(strings.NewReader("ISSN\n0006-2499\n"), Config{Header: true, Columns: Columns{Identifiers: []string{"eISSN"}}}).Validate()
+	if err != nil || len(report.Problems) != 1 || report.Problems[0].Rule != RuleConfig {
+		t.Errorf("Validate: got %v, %v, want a config problem", report.Problems, err)
+	}
+}
@@ -0,0 +1,67 @@
+package csvholdings
+
+import (
+	"errors"
+	"io"
+
+	"github.com/miku/holdings"
+)
+
+// Rule names, see Rules for a description.
+const (
+	RuleConfig     = "config"
+	RuleIdentifier = "identifier"
+	RuleDate       = "date"
+	RuleEmbargo    = "embargo"
+	RuleDateOrder  = "date-order"
+)
+
+// Rules is the catalog of checks performed by Validate.
+var Rules = holdings.Catalog{
+	{Name: RuleConfig, Severity: holdings.Error, Description: "config must be valid and name existing columns"},
+	{Name: RuleIdentifier, Severity: holdings.Error, Description: "row must have at least one identifier"},
+	{Name: RuleDate, Severity: holdings.Error, Description: "dates must match one of the configured layouts"},
+	{Name: RuleEmbargo, Severity: holdings.Error, Description: "embargo must match the configured syntax"},
+	{Name: RuleDateOrder, Severity: holdings.Error, Description: "coverage must not begin after it ends"},
+}
+
+// Validate reads the remaining input and checks every row. Problems are
+// located by line and field. The returned error is only non-nil for I/O
+// errors.
+func (r *Reader) Validate() (holdings.Report, error) {
+	v := holdings.Collector{Rules: Rules}
+	c := r.config
+	for {
+		row, err := r.Read()
+		v.At.Line = r.Line()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, ErrInvalidConfig) || errors.Is(err, ErrUnknownColumn) {
+			v.Add(RuleConfig, "", "", err.Error())
+			return v.Report, nil
+		}
+		if err != nil {
+			return v.Report, err
+		}
+		v.Report.Records++
+		if len(row.Identifiers) == 0 {
+			v.Add(RuleIdentifier, "", "", "no identifier")
+		}
+		begin, berr := c.ParseDate(row.Begin.Date)
+		if berr != nil {
+			v.Add(RuleDate, c.Columns.Begin.Date, row.Begin.Date, berr.Error())
+		}
+		end, eerr := c.ParseDate(row.End.Date)
+		if eerr != nil {
+			v.Add(RuleDate, c.Columns.End.Date, row.End.Date, eerr.Error())
+		}
+		if berr == nil && eerr == nil && begin != "" && end != "" && holdings.CompareDates(begin, end) > 0 {
+			v.Add(RuleDateOrder, c.Columns.End.Date, end, "end before begin "+begin)
+		}
+		if _, _, err := c.ParseEmbargo(row.Embargo); err != nil {
+			v.Add(RuleEmbargo, c.Columns.Embargo, row.Embargo, err.Error())
+		}
+	}
+	return v.Report, nil
+}
@@ -0,0 +1,402 @@
+package holdings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ChangeKind classifies a difference between two holdings snapshots.
+type ChangeKind string
+
+const (
+	Added           ChangeKind = "added"
+	Removed         ChangeKind = "removed"
+	Extended        ChangeKind = "extended"
+	Shrunk          ChangeKind = "shrunk"
+	EmbargoChanged  ChangeKind = "embargo"
+	MetadataChanged ChangeKind = "metadata"
+)
+
+// Change is a single difference for an identifier. Coverage holds the
+// intervals added or removed. Field, Old and New describe changes of moving
+// walls and metadata, where moving walls use the KBART notation, e.g. P1Y.
+type Change struct {
+	ID       string
+	Kind     ChangeKind
+	Title    string
+	Coverage []Entry
+	Field    string
+	Old      string
+	New      string
+}
+
+// Diff compares two snapshots by identifier. Coverage is compared without
+// moving walls, changed moving walls are reported separately. Books have no
+// coverage, their edition and publication date are compared as metadata.
+// Changes are sorted by identifier.
+func Diff(a, b Entries) Changes {
+	ids := make(map[string]bool)
+	for id := range a {
+		ids[id] = true
+	}
+	for id := range b {
+		ids[id] = true
+	}
+	var keys []string
+	for id := range ids {
+		keys = append(keys, id)
+	}
+	sort.Strings(keys)
+
+	var changes Changes
+	for _, id := range keys {
+		xs, ys := entriesOf(a[id]), entriesOf(b[id])
+		nx, ny := len(xs)+len(booksOf(a[id])), len(ys)+len(booksOf(b[id]))
+		title := metadata(b[id], "title")
+		if title == "" {
+			title = metadata(a[id], "title")
+		}
+		switch {
+		case nx == 0 && ny == 0:
+			continue
+		case nx == 0:
+			changes = append(changes, Change{ID: id, Kind: Added, Title: title, Coverage: Normalize(b[id])})
+			continue
+		case ny == 0:
+			changes = append(changes, Change{ID: id, Kind: Removed, Title: title, Coverage: Normalize(a[id])})
+			continue
+		}
+		sx, sy := normalize(static(xs)), normalize(static(ys))
+		if extended := subtractAll(sy, sx); len(extended) > 0 {
+			changes = append(changes, Change{ID: id, Kind: Extended, Title: title, Coverage: normalize(extended)})
+		}
+		if shrunk := subtractAll(sx, sy); len(shrunk) > 0 {
+			changes = append(changes, Change{ID: id, Kind: Shrunk, Title: title, Coverage: normalize(shrunk)})
+		}
+		if before, after := walls(xs), walls(ys); before != after {
+			changes = append(changes, Change{ID: id, Kind: EmbargoChanged, Title: title, Field: "embargo", Old: before, New: after})
+		}
+		for _, field := range []string{"title", "status", "comment", "access", "edition", "date"} {
+			if before, after := metadata(a[id], field), metadata(b[id], field); before != after {
+				changes = append(changes, Change{ID: id, Kind: MetadataChanged, Title: title, Field: field, Old: before, New: after})
+			}
+		}
+	}
+	return changes
+}
+
+// static returns the entries without moving walls and metadata.
+func static(entries []Entry) []Entry {
+	var result []Entry
+	for _, e := range entries {
+		result = append(result, Entry{Begin: e.Begin, End: e.End})
+	}
+	return result
+}
+
+// walls returns the distinct moving walls of a list of entries, sorted and
+// joined by semicolons.
+func walls(entries []Entry) string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, e := range entries {
+		v := FormatEmbargo(e.Embargo, e.EmbargoDisallowEarlier)
+		if v != "" && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return strings.Join(values, "; ")
+}
+
+// FormatEmbargo writes a moving wall in KBART notation, e.g. P1Y for an
+// embargo of one year or R6M for the most recent six months only. A zero
+// duration yields the empty string.
+func FormatEmbargo(d time.Duration, disallowEarlier bool) string {
+	if d == 0 {
+		return ""
+	}
+	if d < 0 {
+		d = -d
+	}
+	prefix := "P"
+	if disallowEarlier {
+		prefix = "R"
+	}
+	switch {
+	case d%Year == 0:
+		return fmt.Sprintf("%s%dY", prefix, d/Year)
+	case d%Month == 0:
+		return fmt.Sprintf("%s%dM", prefix, d/Month)
+	default:
+		return fmt.Sprintf("%s%dD", prefix, (d+Day-1)/Day)
+	}
+}
+
+// metadata returns the distinct values of a metadata field (title, status,
+// comment or access, edition and date for books) of a list of licenses,
+// sorted and joined by semicolons.
+func metadata(licenses []License, field string) string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, l := range licenses {
+		var v string
+		switch l := l.(type) {
+		case Entry:
+			switch field {
+			case "title":
+				v = l.Title
+			case "status":
+				v = l.Status
+			case "comment":
+				v = l.Comment
+			}
+		case Book:
+			switch field {
+			case "title":
+				v = l.Title
+			case "comment":
+				v = l.Comment
+			case "edition":
+				v = l.Edition
+			case "date":
+				v = l.Date
+			}
+		}
+		if field == "access" {
+			v = string(AccessOf(l))
+		}
+		if v != "" && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return strings.Join(values, "; ")
+}
+
+// formatSignature writes a signature as date, volume and issue, e.g.
+// 2001 v12 i3.
+func formatSignature(s Signature) string {
+	var parts []string
+	if s.Date != "" {
+		parts = append(parts, s.Date)
+	}
+	if s.Volume != "" {
+		parts = append(parts, "v"+s.Volume)
+	}
+	if s.Issue != "" {
+		parts = append(parts, "i"+s.Issue)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatRange writes an interval like 1995 v1 - 2003, open ends are left
+// blank. A moving wall is appended in KBART notation.
+func formatRange(e Entry) string {
+	s := formatSignature(e.Begin) + " - " + formatSignature(e.End)
+	s = strings.TrimSpace(s)
+	if emb := FormatEmbargo(e.Embargo, e.EmbargoDisallowEarlier); emb != "" {
+		s = fmt.Sprintf("%s (%s)", s, emb)
+	}
+	return s
+}
+
+// Changes is a list of changes, as returned by Diff.
+type Changes []Change
+
+// WriteText writes one change per line.
+func (c Changes) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, ch := range c {
+		var details string
+		switch ch.Kind {
+		case EmbargoChanged, MetadataChanged:
+			details = fmt.Sprintf("%s: %q -> %q", ch.Field, ch.Old, ch.New)
+		default:
+			var ranges []string
+			for _, e := range ch.Coverage {
+				ranges = append(ranges, formatRange(e))
+			}
+			details = strings.Join(ranges, ", ")
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", ch.ID, ch.Kind, details); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// jsonRange is the JSON representation of a coverage interval.
+type jsonRange struct {
+	Begin   Signature `json:"begin"`
+	End     Signature `json:"end"`
+	Embargo string    `json:"embargo,omitempty"`
+}
+
+// WriteJSON writes one JSON object per change and line.
+func (c Changes) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, ch := range c {
+		v := struct {
+			ID       string      `json:"id"`
+			Kind     ChangeKind  `json:"kind"`
+			Title    string      `json:"title,omitempty"`
+			Coverage []jsonRange `json:"coverage,omitempty"`
+			Field    string      `json:"field,omitempty"`
+			Old      string      `json:"old,omitempty"`
+			New      string      `json:"new,omitempty"`
+		}{ID: ch.ID, Kind: ch.Kind, Title: ch.Title, Field: ch.Field, Old: ch.Old, New: ch.New}
+		for _, e := range ch.Coverage {
+			v.Coverage = append(v.Coverage, jsonRange{
+				Begin:   e.Begin,
+				End:     e.End,
+				Embargo: FormatEmbargo(e.Embargo, e.EmbargoDisallowEarlier),
+			})
+		}
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteKBART writes KBART style rows, preceded by a header. The first column
+// names the kind of change, coverage changes get a row per interval, other
+// changes are described in the notes column. Entries do not record, whether
+// an identifier is a print or an online identifier, so all identifiers go
+// into print_identifier; WriteKBARTRoles can tell them apart.
+func (c Changes) WriteKBART(w io.Writer) error {
+	return c.WriteKBARTRoles(w, nil)
+}
+
+// WriteKBARTRoles writes KBART style rows like WriteKBART, with identifiers,
+// for which online returns true, in online_identifier. The print and online
+// identifier of a title share a row, if both changes name the same title
+// and are otherwise equal.
+func (c Changes) WriteKBARTRoles(w io.Writer, online func(id string) bool) error {
+	header := []string{"change", "publication_title", "print_identifier", "online_identifier",
+		"date_first_issue_online", "num_first_vol_online", "num_first_issue_online",
+		"date_last_issue_online", "num_last_vol_online", "num_last_issue_online",
+		"embargo_info", "coverage_notes"}
+	if _, err := io.WriteString(w, strings.Join(header, "\t")+"\n"); err != nil {
+		return err
+	}
+	for _, p := range c.pairs(online) {
+		ch := p.change
+		row := func(ch Change, e Entry, embargo, notes string) error {
+			_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				ch.Kind, ch.Title, p.printID, p.onlineID,
+				e.Begin.Date, e.Begin.Volume, e.Begin.Issue,
+				e.End.Date, e.End.Volume, e.End.Issue, embargo, notes)
+			return err
+		}
+		switch ch.Kind {
+		case EmbargoChanged:
+			if err := row(ch, Entry{}, ch.New, fmt.Sprintf("embargo: %s -> %s", ch.Old, ch.New)); err != nil {
+				return err
+			}
+		case MetadataChanged:
+			if err := row(ch, Entry{}, "", fmt.Sprintf("%s: %s -> %s", ch.Field, ch.Old, ch.New)); err != nil {
+				return err
+			}
+		default:
+			if len(ch.Coverage) == 0 {
+				// books have no coverage
+				if err := row(ch, Entry{}, "", ""); err != nil {
+					return err
+				}
+			}
+			for _, e := range ch.Coverage {
+				if err := row(ch, e, FormatEmbargo(e.Embargo, e.EmbargoDisallowEarlier), ""); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// pair is a change of a print identifier, an online identifier or both.
+type pair struct {
+	change            Change
+	printID, onlineID string
+}
+
+// changeKey identifies a change regardless of the identifier.
+type changeKey struct {
+	kind                        ChangeKind
+	title, field, before, after string
+	coverage                    string
+}
+
+func keyOf(ch Change) changeKey {
+	var ranges []string
+	for _, e := range ch.Coverage {
+		ranges = append(ranges, formatRange(e))
+	}
+	return changeKey{ch.Kind, ch.Title, ch.Field, ch.Old, ch.New, strings.Join(ranges, ", ")}
+}
+
+// pairs assigns changes to print and online identifiers, in the order of
+// their first identifier. A change of a print identifier is joined with the
+// same change of an online identifier, if the change names a title.
+func (c Changes) pairs(online func(id string) bool) []pair {
+	isOnline := func(id string) bool {
+		return online != nil && online(id)
+	}
+	var result []pair
+	// waiting changes of the other role, by key
+	waiting := make(map[changeKey][]int)
+	for _, ch := range c {
+		p := pair{change: ch, printID: ch.ID}
+		if isOnline(ch.ID) {
+			p = pair{change: ch, onlineID: ch.ID}
+		}
+		if ch.Title == "" {
+			result = append(result, p)
+			continue
+		}
+		k := keyOf(ch)
+		joined := false
+		for j, i := range waiting[k] {
+			other := &result[i]
+			switch {
+			case p.printID != "" && other.printID == "":
+				other.printID = p.printID
+			case p.onlineID != "" && other.onlineID == "":
+				other.onlineID = p.onlineID
+			default:
+				continue
+			}
+			waiting[k] = append(waiting[k][:j], waiting[k][j+1:]...)
+			joined = true
+			break
+		}
+		if !joined {
+			waiting[k] = append(waiting[k], len(result))
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Write writes the changes in one of the formats text, json or kbart.
+func (c Changes) Write(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		return c.WriteText(w)
+	case "json":
+		return c.WriteJSON(w)
+	case "kbart":
+		return c.WriteKBART(w)
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
@@ -0,0 +1,107 @@
+package holdings
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := span("1990", "2005")
+	old.Title = "Journal of Things"
+	next := span("1995", "2010")
+	next.Title = "Journal of Things and Stuff"
+	next.Embargo = -1 * Year
+
+	a := Entries{
+		"1234-5678": []License{old},
+		"0000-0001": []License{span("2000", "")},
+	}
+	b := Entries{
+		"1234-5678": []License{next},
+		"0000-0002": []License{span("2001", "2002")},
+	}
+
+	want := Changes{
+		{ID: "0000-0001", Kind: Removed, Coverage: []Entry{span("2000", "")}},
+		{ID: "0000-0002", Kind: Added, Coverage: []Entry{span("2001", "2002")}},
+		{ID: "1234-5678", Kind: Extended, Title: next.Title, Coverage: []Entry{span("2006", "2010")}},
+		{ID: "1234-5678", Kind: Shrunk, Title: next.Title, Coverage: []Entry{span("1990", "1994")}},
+		{ID: "1234-5678", Kind: EmbargoChanged, Title: next.Title, Field: "embargo", Old: "", New: "P1Y"},
+		{ID: "1234-5678", Kind: MetadataChanged, Title: next.Title, Field: "title", Old: old.Title, New: next.Title},
+	}
+	got := Diff(a, b)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff: got %+v, want %+v", got, want)
+	}
+
+	books := Diff(Entries{"9783161484100": []License{Book{Title: "Things", Edition: "2"}}},
+		Entries{"9783161484100": []License{Book{Title: "Things", Edition: "3"}}, "9780306406157": []License{Book{}}})
+	wantBooks := Changes{
+		{ID: "9780306406157", Kind: Added},
+		{ID: "9783161484100", Kind: MetadataChanged, Title: "Things", Field: "edition", Old: "2", New: "3"},
+	}
+	if !reflect.DeepEqual(books, wantBooks) {
+		t.Errorf("Diff: got %+v, want %+v", books, wantBooks)
+	}
+
+	var buf bytes.Buffer
+	if err := got[:3].Write(&buf, "text"); err != nil {
+		t.Fatal(err)
+	}
+	wantText := "0000-0001  removed   2000 -\n0000-0002  added     2001 - 2002\n1234-5678  extended  2006 - 2010\n"
+	if buf.String() != wantText {
+		t.Errorf("WriteText: got %q, want %q", buf.String(), wantText)
+	}
+	if err := got.Write(&buf, "yaml"); err == nil {
+		t.Error("Write: expected error for unknown format")
+	}
+}
+
+func TestWriteKBART(t *testing.T) {
+	e := span("2000", "")
+	e.Title = "Things"
+	changes := Diff(Entries{}, Entries{
+		"0006-2499": []License{e},
+		"1521-4036": []License{e},
+		"1613-4141": []License{span("2001", "")},
+		"2049-3630": []License{span("2001", "")},
+	})
+	online := map[string]bool{"1521-4036": true, "2049-3630": true}
+	var cases = []struct {
+		about  string
+		online func(string) bool
+		want   []string
+	}{
+		{
+			about:  "roles unknown",
+			online: nil,
+			want: []string{
+				"added\tThings\t0006-2499\t\t2000\t\t\t\t\t\t\t",
+				"added\tThings\t1521-4036\t\t2000\t\t\t\t\t\t\t",
+				"added\t\t1613-4141\t\t2001\t\t\t\t\t\t\t",
+				"added\t\t2049-3630\t\t2001\t\t\t\t\t\t\t",
+			},
+		},
+		{
+			about:  "print and online of a title, untitled changes stay apart",
+			online: func(id string) bool { return online[id] },
+			want: []string{
+				"added\tThings\t0006-2499\t1521-4036\t2000\t\t\t\t\t\t\t",
+				"added\t\t1613-4141\t\t2001\t\t\t\t\t\t\t",
+				"added\t\t\t2049-3630\t2001\t\t\t\t\t\t\t",
+			},
+		},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := changes.WriteKBARTRoles(&buf, c.online); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if !reflect.DeepEqual(lines[1:], c.want) {
+			t.Errorf("%s: got %q, want %q", c.about, lines[1:], c.want)
+		}
+	}
+}
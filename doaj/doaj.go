@@ -0,0 +1,195 @@
+// Package doaj reads the journal CSV dump of the Directory of Open Access
+// Journals (DOAJ), as downloaded from https://doaj.org/csv. Every journal
+// becomes a free access entry under its ISSNs, with coverage beginning in
+// the year, since when the journal publishes all content under an open
+// license. Journals without that year are skipped: an entry without dates
+// cannot confirm a record with a date, and earlier content may not be open.
+package doaj
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/miku/holdings"
+)
+
+var (
+	ErrMissingColumns     = errors.New("missing ISSN columns")
+	ErrMissingIdentifiers = errors.New("missing identifiers")
+)
+
+var yearPattern = regexp.MustCompile(`^(1[5-9]\d{2}|20\d{2})$`)
+
+// Columns lists the accepted header names per field, matched without regard
+// to case. Older dumps use shorter names.
+var Columns = map[string][]string{
+	"title": {"journal title", "title"},
+	"issn":  {"journal issn (print version)", "issn", "pissn"},
+	"eissn": {"journal eissn (online version)", "eissn"},
+	"url":   {"journal url", "url"},
+	"since": {"when did the journal start to publish all content using an open license?", "first calendar year journal provided online open access content"},
+}
+
+// Journal is a journal of the directory.
+type Journal struct {
+	Title string
+	ISSN  string
+	EISSN string
+	URL   string
+	Since string
+}
+
+// Identifiers returns the ISSNs of a journal in the form 1234-567X.
+func (j Journal) Identifiers() []string {
+	var ids []string
+	for _, s := range []string{j.ISSN, j.EISSN} {
+		if issn, ok := holdings.NormalizeISSN(s); ok {
+			ids = append(ids, issn)
+		}
+	}
+	return ids
+}
+
+// Entry returns the free access coverage of a journal and reports, whether
+// the journal tells, since when its content is open.
+func (j Journal) Entry() (holdings.Entry, bool) {
+	entry := holdings.Entry{
+		Title:  j.Title,
+		URL:    j.URL,
+		Access: holdings.FreeAccess,
+	}
+	if !yearPattern.MatchString(j.Since) {
+		return entry, false
+	}
+	entry.Begin.Date = j.Since
+	return entry, true
+}
+
+// Reader reads a DOAJ journal CSV dump. Journals without a print or online
+// ISSN cannot be looked up; Policy decides about them, by default they are
+// left out and listed in the returned holdings.ParseError.
+type Reader struct {
+	r      *csv.Reader
+	index  map[string]int
+	record []string
+	Policy holdings.ErrorPolicy
+}
+
+func NewReader(r io.Reader) *Reader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	return &Reader{
+		r:      cr,
+		Policy: holdings.ErrorPolicy{Action: holdings.SkipAndCollect},
+	}
+}
+
+// header reads the header row and finds the columns.
+func (r *Reader) header() error {
+	record, err := r.r.Read()
+	if err != nil {
+		return err
+	}
+	names := make(map[string]int)
+	for i, name := range record {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		names[name] = i
+	}
+	r.index = make(map[string]int)
+	for field, candidates := range Columns {
+		for _, name := range candidates {
+			if i, ok := names[name]; ok {
+				r.index[field] = i
+				break
+			}
+		}
+	}
+	_, issn := r.index["issn"]
+	_, eissn := r.index["eissn"]
+	if !issn && !eissn {
+		return ErrMissingColumns
+	}
+	return nil
+}
+
+// Read returns the next journal. At the end of the input io.EOF is
+// returned.
+func (r *Reader) Read() (Journal, error) {
+	var j Journal
+	if r.index == nil {
+		if err := r.header(); err != nil {
+			return j, err
+		}
+	}
+	record, err := r.r.Read()
+	if err != nil {
+		return j, err
+	}
+	r.record = record
+	get := func(field string) string {
+		if i, ok := r.index[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	return Journal{
+		Title: get("title"),
+		ISSN:  get("issn"),
+		EISSN: get("eissn"),
+		URL:   get("url"),
+		Since: get("since"),
+	}, nil
+}
+
+// Line returns the line of the journal read last.
+func (r *Reader) Line() int {
+	if r.record == nil {
+		return 0
+	}
+	line, _ := r.r.FieldPos(0)
+	return line
+}
+
+func (r *Reader) ReadAll() (holdings.Entries, error) {
+	entries := make(holdings.Entries)
+
+	perr := holdings.ParseError{}
+
+	for {
+		j, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		ids := j.Identifiers()
+		if len(ids) == 0 {
+			rerr := &holdings.RecordError{
+				Line:    r.Line(),
+				Record:  j.Title,
+				Snippet: strings.Join(r.record, ","),
+				Err:     ErrMissingIdentifiers,
+			}
+			if err := r.Policy.Handle(&perr, rerr); err != nil {
+				return entries, err
+			}
+			continue
+		}
+		entry, ok := j.Entry()
+		if !ok {
+			continue
+		}
+		for _, id := range ids {
+			entries[id] = append(entries[id], entry)
+		}
+	}
+	if len(perr.Errors) > 0 {
+		return entries, perr
+	}
+	return entries, nil
+}
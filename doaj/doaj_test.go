@@ -0,0 +1,56 @@
+package doaj
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miku/holdings"
+)
+
+const dump = `Journal title,Journal URL,URL in DOAJ,When did the journal start to publish all content using an open license?,Alternative title,Journal ISSN (print version),Journal EISSN (online version)
+Journal of Things,https://things.example.org,https://doaj.org/toc/1,2005,,0028-0836,1476-4687
+"Annals, of Stuff",https://stuff.example.org,https://doaj.org/toc/2,,,,0036-8075
+Nameless,,,,,,
+`
+
+func TestReadAll(t *testing.T) {
+	entries, err := NewReader(strings.NewReader(dump)).ReadAll()
+	var perr holdings.ParseError
+	if !errors.As(err, &perr) || len(perr.Errors) != 1 || !errors.Is(err, ErrMissingIdentifiers) {
+		t.Fatalf("ReadAll: got %v, want a single missing identifiers error", err)
+	}
+	things := holdings.Entry{
+		Begin:  holdings.Signature{Date: "2005"},
+		Title:  "Journal of Things",
+		URL:    "https://things.example.org",
+		Access: holdings.FreeAccess,
+	}
+	want := holdings.Entries{
+		"0028-0836": []holdings.License{things},
+		"1476-4687": []holdings.License{things},
+		// journals without a year are skipped
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ReadAll: got %+v, want %+v", entries, want)
+	}
+	d := holdings.Check(entries, holdings.Record{Identifiers: []string{"1476-4687"}, Signature: holdings.Signature{Date: "2010"}})
+	if !d.OK || d.Access != holdings.FreeAccess {
+		t.Errorf("Check: got %+v, want free access", d)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	report, err := NewReader(strings.NewReader(dump)).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rules []string
+	for _, p := range report.Problems {
+		rules = append(rules, p.Rule)
+	}
+	if want := []string{RuleYear, RuleIdentifier, RuleYear}; !reflect.DeepEqual(rules, want) || report.Records != 3 {
+		t.Errorf("Validate: got %v in %d records, want %v in 3", rules, report.Records, want)
+	}
+}
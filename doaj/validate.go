@@ -0,0 +1,59 @@
+package doaj
+
+import (
+	"io"
+
+	"github.com/miku/holdings"
+)
+
+// Rule names, see Rules for a description.
+const (
+	RuleColumns    = "columns"
+	RuleIdentifier = "identifier"
+	RuleISSN       = "issn"
+	RuleYear       = "year"
+)
+
+// Rules is the catalog of checks performed by Validate.
+var Rules = holdings.Catalog{
+	{Name: RuleColumns, Severity: holdings.Error, Description: "header must name ISSN columns"},
+	{Name: RuleIdentifier, Severity: holdings.Error, Description: "journal must have a print or online ISSN"},
+	{Name: RuleISSN, Severity: holdings.Error, Description: "ISSN must have a valid check digit"},
+	{Name: RuleYear, Severity: holdings.Warning, Description: "open license start should be a year, the journal is skipped otherwise"},
+}
+
+// Validate reads the remaining input and checks every journal. Problems are
+// located by line. The returned error is only non-nil for I/O errors.
+func (r *Reader) Validate() (holdings.Report, error) {
+	v := holdings.Collector{Rules: Rules}
+	for {
+		j, err := r.Read()
+		v.At.Line = r.Line()
+		if err == io.EOF {
+			break
+		}
+		if err == ErrMissingColumns {
+			v.Add(RuleColumns, "", "", err.Error())
+			return v.Report, nil
+		}
+		if err != nil {
+			return v.Report, err
+		}
+		v.Report.Records++
+		if len(j.Identifiers()) == 0 {
+			v.Add(RuleIdentifier, "", "", "no ISSN")
+		}
+		for _, f := range [][2]string{{"issn", j.ISSN}, {"eissn", j.EISSN}} {
+			if f[1] != "" && !holdings.ValidISSN(f[1]) {
+				v.Add(RuleISSN, f[0], f[1], "invalid ISSN")
+			}
+		}
+		switch {
+		case j.Since == "":
+			v.Add(RuleYear, "since", j.Since, "missing year")
+		case !yearPattern.MatchString(j.Since):
+			v.Add(RuleYear, "since", j.Since, "not a year")
+		}
+	}
+	return v.Report, nil
+}
@@ -0,0 +1,61 @@
+package holdings_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/google"
+	"github.com/miku/holdings/kbart"
+	"github.com/miku/holdings/ovid"
+)
+
+// TestEquivalentEmbargoes checks, that "last 365 days unavailable" restricts
+// the same records in all formats.
+func TestEquivalentEmbargoes(t *testing.T) {
+	var files = []struct {
+		format string
+		file   holdings.File
+	}{
+		{"kbart", kbart.NewReader(strings.NewReader(
+			"publication_title\tprint_identifier\tonline_identifier\tdate_first_issue_online\tnum_first_vol_online\tnum_first_issue_online\tdate_last_issue_online\tnum_last_vol_online\tnum_last_issue_online\ttitle_url\tfirst_author\ttitle_id\tembargo_info\tcoverage_depth\tnotes\tpublisher_name\tpublication_type\tdate_monograph_published_print\tdate_monograph_published_online\tmonograph_volume\tmonograph_edition\tfirst_editor\tparent_publication_title_id\tpreceding_publication_title_id\taccess_type\n" +
+				"J\t0006-2499\t\t1990\t\t\t\t\t\thttp://example.com\t\t1\tP1Y\tfulltext\t\t\t\t\t\t\t\t\t\t\t\n"))},
+		{"ovid", ovid.NewReader(strings.NewReader(`<holdings><holding ezb_id="1">
+			<EZBIssns><p-issn>0006-2499</p-issn></EZBIssns>
+			<entitlements><entitlement><begin><year>1990</year><delay>-1Y</delay></begin></entitlement></entitlements>
+			</holding></holdings>`))},
+		{"google", google.NewReader(strings.NewReader(`<institutional_holdings><item type="electronic">
+			<title>J</title><issn>0006-2499</issn>
+			<coverage><from><year>1990</year></from><embargo><days_not_available>365</days_not_available></embargo></coverage>
+			</item></institutional_holdings>`))},
+	}
+
+	var cases = []struct {
+		age        time.Duration
+		restricted bool
+	}{
+		{0, true},
+		{200 * holdings.Day, true},
+		{364 * holdings.Day, true},
+		{366 * holdings.Day, false},
+		{10 * holdings.Year, false},
+	}
+
+	for _, f := range files {
+		entries, err := f.file.ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %s", f.format, err)
+		}
+		licenses := entries.Licenses("0006-2499")
+		if len(licenses) != 1 {
+			t.Fatalf("%s: got %d licenses, want 1", f.format, len(licenses))
+		}
+		for _, c := range cases {
+			err := licenses[0].TimeRestricted(time.Now().Add(-c.age))
+			if (err == holdings.ErrMovingWall) != c.restricted {
+				t.Errorf("%s: record from %s ago got %v, want restricted %v", f.format, c.age, err, c.restricted)
+			}
+		}
+	}
+}
@@ -0,0 +1,350 @@
+// Package ezb reads license data exported from the Elektronische
+// Zeitschriftenbibliothek (EZB), e.g. Lizenzdaten or Nationallizenzen. The
+// traffic light colour of a period becomes the access type of an entry.
+package ezb
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/internal/snippet"
+)
+
+// Traffic light colours as used by the EZB.
+const (
+	Green     = "green"      // freely available
+	Yellow    = "yellow"     // licensed
+	YellowRed = "yellow_red" // licensed in parts
+	Red       = "red"        // not available
+)
+
+// colorCodes are the numeric forms of the colours.
+var colorCodes = map[string]string{
+	"1": Green,
+	"2": Yellow,
+	"3": YellowRed,
+	"4": Red,
+	"6": YellowRed,
+}
+
+var ErrPeriod = errors.New("cannot parse period")
+
+var (
+	// ab 1995, bis 2003, 1995 - 2003, ab Vol. 10 (1995) bis Vol. 20 (2005)
+	fromPattern  = regexp.MustCompile(`(?i)^(?:ab|from|since)\s+(.+?)(?:\s+(?:bis|to|until)\s+(.+))?$`)
+	untilPattern = regexp.MustCompile(`(?i)^(?:bis|to|until)\s+(.+)$`)
+	rangePattern = regexp.MustCompile(`^(.+?)\s*[-–]\s*(.*)$`)
+
+	volumePattern = regexp.MustCompile(`(?i)\b(?:vol|bd|jg|jahrgang|band|volume)\.?\s*(\d+)`)
+	issuePattern  = regexp.MustCompile(`(?i)\b(?:no|nr|h|heft|iss|issue)\.?\s*(\d+)`)
+	yearPattern   = regexp.MustCompile(`\b(1[5-9]\d{2}|20\d{2})\b`)
+	zdbPattern    = regexp.MustCompile(`^(\d+)\.(\d{4})(?:\s*,\s*(\d+))?$`)
+
+	// moving walls, e.g. (ohne die letzten 12 Monate), last 2 years not available
+	wallPattern = regexp.MustCompile(`(?i)\(?\s*(?:ohne\s+(?:die\s+)?letzten|(?:most\s+recent|last))\s+(\d+)\s+(jahr|monat|tag|year|month|day)\w*[^)]*\)?`)
+)
+
+// Journal is a journal in an EZB export.
+type Journal struct {
+	ID      string   `xml:"jourid,attr" json:"id"`
+	Title   string   `xml:"title" json:"title"`
+	ZDBID   []string `xml:"detail>ZDB_number" json:"zdbid"`
+	PISSN   []string `xml:"detail>P_ISSNs>P_ISSN" json:"pissn"`
+	EISSN   []string `xml:"detail>E_ISSNs>E_ISSN" json:"eissn"`
+	Color   Color    `xml:"journal_color" json:"color"`
+	Periods []Period `xml:"detail>periods>period" json:"periods"`
+}
+
+// Color is a traffic light colour, either by name or by code.
+type Color struct {
+	Name string `xml:"color,attr" json:"name"`
+	Code string `xml:"color_code,attr" json:"code"`
+}
+
+// String returns the colour name.
+func (c Color) String() string {
+	if c.Name != "" {
+		return strings.ToLower(c.Name)
+	}
+	return colorCodes[c.Code]
+}
+
+// Period is a period of availability with its own colour.
+type Period struct {
+	Color    string `xml:"color,attr" json:"color"`
+	Code     string `xml:"color_code,attr" json:"code"`
+	Readable string `xml:"readable,attr" json:"readable"`
+	Label    string `xml:"label" json:"label"`
+	URL      string `xml:"warpto_link>url" json:"url"`
+}
+
+// color returns the colour of the period or the colour of the journal.
+func (p Period) color(j Journal) string {
+	if c := (Color{Name: p.Color, Code: p.Code}).String(); c != "" {
+		return c
+	}
+	return j.Color.String()
+}
+
+// readable tells, whether content of the period can be read. If the export
+// does not tell, the colour decides.
+func (p Period) readable(color string) bool {
+	switch strings.ToLower(p.Readable) {
+	case "yes", "true", "1", "ja":
+		return true
+	case "no", "false", "0", "nein":
+		return false
+	}
+	return color != Red && color != ""
+}
+
+// Entry parses the label of a period, a period without label is open.
+func (p Period) Entry() (holdings.Entry, error) {
+	if strings.TrimSpace(p.Label) == "" {
+		return holdings.Entry{Open: true}, nil
+	}
+	return ParsePeriod(p.Label)
+}
+
+// periods returns the periods of a journal. A journal without periods is
+// available as a whole, as far as its colour allows.
+func (j Journal) periods() []Period {
+	if len(j.Periods) == 0 {
+		return []Period{{}}
+	}
+	return j.Periods
+}
+
+// Access returns the access type for a colour. Only parts of a yellow_red
+// period are licensed, which parts the export does not tell, so it has no
+// access type.
+func Access(color string) holdings.AccessType {
+	switch color {
+	case Green:
+		return holdings.FreeAccess
+	case Yellow:
+		return holdings.LicensedAccess
+	}
+	return ""
+}
+
+// EZBKey returns the key, under which a journal can be looked up by EZB-ID.
+// ZDB-IDs are keyed by holdings.ZDBKey, ISSNs are used as is.
+func EZBKey(id string) string { return "ezb:" + strings.TrimSpace(id) }
+
+// Identifiers returns the keys of a journal: EZB-ID, ZDB-IDs and ISSNs.
+func (j Journal) Identifiers() []string {
+	var ids []string
+	if j.ID != "" {
+		ids = append(ids, EZBKey(j.ID))
+	}
+	for _, id := range j.ZDBID {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, holdings.ZDBKey(id))
+		}
+	}
+	for _, issn := range append(append([]string{}, j.PISSN...), j.EISSN...) {
+		if issn = strings.TrimSpace(issn); issn != "" {
+			ids = append(ids, issn)
+		}
+	}
+	return ids
+}
+
+// Reader reads EZB XML exports. Only readable periods are read. By default,
+// errors in single journals are collected and returned as
+// holdings.ParseError. If Colors is not empty, only periods with one of the
+// given colours are read. Periods licensed in parts (yellow_red) are
+// skipped, unless PartlyLicensed is set; they are then read as licensed.
+type Reader struct {
+	r              io.Reader
+	Policy         holdings.ErrorPolicy
+	Colors         []string
+	PartlyLicensed bool
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:      bufio.NewReader(r),
+		Policy: holdings.ErrorPolicy{Action: holdings.SkipAndCollect},
+	}
+}
+
+func (r *Reader) ReadAll() (holdings.Entries, error) {
+	entries := make(holdings.Entries)
+	sr := snippet.NewReader(r.r)
+	decoder := xml.NewDecoder(sr)
+
+	// collect errors, if the policy says so
+	perr := holdings.ParseError{}
+
+	for {
+		line, column := decoder.InputPos()
+		sr.Mark(decoder.InputOffset())
+
+		t, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "journal" {
+			continue
+		}
+		var j Journal
+		recordError := func(err error) *holdings.RecordError {
+			return &holdings.RecordError{
+				Line:    line,
+				Column:  column,
+				Record:  attr(se, "jourid"),
+				Snippet: sr.String(),
+				Err:     err,
+			}
+		}
+		if err := decoder.DecodeElement(&j, &se); err != nil {
+			if err := r.Policy.Handle(&perr, recordError(err)); err != nil {
+				return entries, err
+			}
+			continue
+		}
+		for _, p := range j.periods() {
+			color := p.color(j)
+			if !p.readable(color) || !r.accept(color) {
+				continue
+			}
+			if color == YellowRed && !r.PartlyLicensed {
+				continue
+			}
+			entry, err := p.Entry()
+			if err != nil {
+				if err := r.Policy.Handle(&perr, recordError(err)); err != nil {
+					return entries, err
+				}
+				continue
+			}
+			entry.Status = color
+			entry.Access = Access(color)
+			if color == YellowRed {
+				entry.Access = holdings.LicensedAccess
+			}
+			entry.Title = strings.TrimSpace(j.Title)
+			entry.URL = strings.TrimSpace(p.URL)
+			for _, id := range j.Identifiers() {
+				entries[id] = append(entries[id], entry)
+			}
+		}
+	}
+	if len(perr.Errors) > 0 {
+		return entries, perr
+	}
+	return entries, nil
+}
+
+// accept returns true, if a period with the given colour should be read.
+func (r *Reader) accept(color string) bool {
+	if len(r.Colors) == 0 {
+		return true
+	}
+	for _, c := range r.Colors {
+		if c == color {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePeriod parses period strings like "ab 1995", "bis 2003", "1995 -
+// 2003" or "ab Vol. 10 (1995) bis Vol. 20 (2005)". A note on a moving wall,
+// like "(ohne die letzten 12 Monate)", becomes an embargo. A period without
+// a year, e.g. a moving wall only or "ab Vol. 10", is open by date.
+func ParsePeriod(s string) (holdings.Entry, error) {
+	var entry holdings.Entry
+	s = strings.TrimSpace(s)
+	if ms := wallPattern.FindStringSubmatch(s); ms != nil {
+		n, _ := strconv.Atoi(ms[1])
+		unit := holdings.Day
+		switch strings.ToLower(ms[2]) {
+		case "jahr", "year":
+			unit = holdings.Year
+		case "monat", "month":
+			unit = holdings.Month
+		}
+		entry.Embargo = -time.Duration(n) * unit
+		s = strings.TrimSpace(strings.Replace(s, ms[0], "", 1))
+	}
+	var begin, end string
+	switch {
+	case s == "":
+		if entry.Embargo != 0 {
+			entry.Open = true
+			return entry, nil
+		}
+		return entry, ErrPeriod
+	case fromPattern.MatchString(s):
+		ms := fromPattern.FindStringSubmatch(s)
+		begin, end = ms[1], ms[2]
+	case untilPattern.MatchString(s):
+		end = untilPattern.FindStringSubmatch(s)[1]
+	case zdbPattern.MatchString(s):
+		begin, end = s, s
+	case rangePattern.MatchString(s):
+		ms := rangePattern.FindStringSubmatch(s)
+		begin, end = ms[1], ms[2]
+	default:
+		begin, end = s, s
+	}
+	var err error
+	if begin != "" {
+		if entry.Begin, err = parseSignature(begin); err != nil {
+			return entry, err
+		}
+	}
+	if end != "" {
+		if entry.End, err = parseSignature(end); err != nil {
+			return entry, err
+		}
+	}
+	entry.Open = entry.Begin.Date == "" && entry.End.Date == ""
+	return entry, nil
+}
+
+// parseSignature parses one side of a period.
+func parseSignature(s string) (holdings.Signature, error) {
+	var sig holdings.Signature
+	s = strings.TrimSpace(s)
+	if ms := zdbPattern.FindStringSubmatch(s); ms != nil {
+		return holdings.Signature{Volume: ms[1], Date: ms[2], Issue: ms[3]}, nil
+	}
+	if ms := volumePattern.FindStringSubmatch(s); ms != nil {
+		sig.Volume = ms[1]
+	}
+	if ms := issuePattern.FindStringSubmatch(s); ms != nil {
+		sig.Issue = ms[1]
+	}
+	if ms := yearPattern.FindStringSubmatch(s); ms != nil {
+		sig.Date = ms[1]
+	}
+	if sig == (holdings.Signature{}) {
+		return sig, ErrPeriod
+	}
+	return sig, nil
+}
+
+// attr returns the value of an attribute or the empty string.
+func attr(se xml.StartElement, name string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package ezb
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miku/holdings"
+)
+
+const doc = `<ezb_export>
+<journal jourid="1234">
+  <title>Journal of Things</title>
+  <detail>
+    <P_ISSNs><P_ISSN>0006-2499</P_ISSN></P_ISSNs>
+    <ZDB_number>2345678-9</ZDB_number>
+    <periods>
+      <period color="green"><label>1990 - 1994</label></period>
+      <period color_code="2" readable="yes"><label>ab 1995 (ohne die letzten 12 Monate)</label></period>
+      <period color="red"><label>bis 1989</label></period>
+      <period color="yellow_red" readable="yes"><label>ab 2010</label></period>
+    </periods>
+  </detail>
+</journal>
+<journal jourid="5678">
+  <detail><periods><period color="yellow"><label>irgendwann</label></period></periods></detail>
+</journal>
+</ezb_export>`
+
+func TestReadAll(t *testing.T) {
+	entries, err := NewReader(strings.NewReader(doc)).ReadAll()
+	var perr holdings.ParseError
+	if !errors.As(err, &perr) || len(perr.Errors) != 1 {
+		t.Fatalf("ReadAll: got %v, want a single error", err)
+	}
+	want := []holdings.License{
+		holdings.Entry{
+			Begin:  holdings.Signature{Date: "1990"},
+			End:    holdings.Signature{Date: "1994"},
+			Status: Green,
+			Title:  "Journal of Things",
+			Access: holdings.FreeAccess,
+		},
+		holdings.Entry{
+			Begin:   holdings.Signature{Date: "1995"},
+			Embargo: -12 * holdings.Month,
+			Status:  Yellow,
+			Title:   "Journal of Things",
+			Access:  holdings.LicensedAccess,
+		},
+	}
+	for _, id := range []string{"0006-2499", EZBKey("1234"), holdings.ZDBKey("2345678-9")} {
+		if got := entries.Licenses(id); !reflect.DeepEqual(got, want) {
+			t.Errorf("Licenses(%s): got %+v, want %+v", id, got, want)
+		}
+	}
+}
+
+func TestReadAllPartlyLicensed(t *testing.T) {
+	r := NewReader(strings.NewReader(doc))
+	r.PartlyLicensed = true
+	entries, _ := r.ReadAll()
+	licenses := entries.Licenses("0006-2499")
+	if len(licenses) != 3 {
+		t.Fatalf("got %d licenses, want 3", len(licenses))
+	}
+	want := holdings.Entry{
+		Begin:  holdings.Signature{Date: "2010"},
+		Status: YellowRed,
+		Title:  "Journal of Things",
+		Access: holdings.LicensedAccess,
+	}
+	if licenses[2] != want {
+		t.Errorf("got %+v, want %+v", licenses[2], want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	doc := `<ezb_export>
+<journal jourid="1"><title>No Periods</title><journal_color color="green"/></journal>
+<journal jourid="2"><detail><periods><period color="green"><label></label></period></periods></detail></journal>
+<journal jourid="3"><detail><periods><period color="yellow"><label>ohne die letzten 12 Monate</label></period></periods></detail></journal>
+</ezb_export>`
+	entries, err := NewReader(strings.NewReader(doc)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := time.Now().Format("2006")
+	var cases = []struct {
+		id   string
+		date string
+		ok   bool
+	}{
+		{"1", "1850", true},
+		{"2", "1850", true},
+		{"3", "1850", true},
+		{"3", last, false},
+	}
+	for _, c := range cases {
+		r := holdings.Record{Identifiers: []string{EZBKey(c.id)}, Signature: holdings.Signature{Date: c.date}}
+		if d := holdings.Check(entries, r); d.OK != c.ok {
+			t.Errorf("Check(%s, %s): got %v (%v), want %v", c.id, c.date, d.OK, d.Err, c.ok)
+		}
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	var cases = []struct {
+		s    string
+		want holdings.Entry
+		err  error
+	}{
+		{"ab 1995", holdings.Entry{Begin: holdings.Signature{Date: "1995"}}, nil},
+		{"bis 2003", holdings.Entry{End: holdings.Signature{Date: "2003"}}, nil},
+		{"1995 - 2003", holdings.Entry{
+			Begin: holdings.Signature{Date: "1995"}, End: holdings.Signature{Date: "2003"}}, nil},
+		{"ab Vol. 10 (1995) bis Vol. 20 (2005)", holdings.Entry{
+			Begin: holdings.Signature{Date: "1995", Volume: "10"},
+			End:   holdings.Signature{Date: "2005", Volume: "20"}}, nil},
+		{"2001", holdings.Entry{
+			Begin: holdings.Signature{Date: "2001"}, End: holdings.Signature{Date: "2001"}}, nil},
+		{"ab 1995 (ohne die letzten 2 Jahre)", holdings.Entry{
+			Begin: holdings.Signature{Date: "1995"}, Embargo: -2 * holdings.Year}, nil},
+		{"ohne die letzten 12 Monate", holdings.Entry{Embargo: -12 * holdings.Month, Open: true}, nil},
+		{"ab Vol. 10", holdings.Entry{Begin: holdings.Signature{Volume: "10"}, Open: true}, nil},
+		{"irgendwann", holdings.Entry{}, ErrPeriod},
+	}
+	for _, c := range cases {
+		got, err := ParsePeriod(c.s)
+		if err != c.err {
+			t.Errorf("ParsePeriod(%q): got error %v, want %v", c.s, err, c.err)
+		}
+		if err == nil && !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ParsePeriod(%q): got %+v, want %+v", c.s, got, c.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	report, err := NewReader(strings.NewReader(doc)).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range report.Problems {
+		got = append(got, p.Rule+" "+p.Path)
+	}
+	want := []string{
+		"identifier /ezb_export/journal[2]",
+		"period /ezb_export/journal[2]/detail/periods/period[1]/label",
+	}
+	if report.Records != 2 || !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate: got %d records, %v, want 2, %v", report.Records, got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package ezb
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/miku/holdings"
+)
+
+// Rule names, see Rules for a description.
+const (
+	RuleXML        = "xml"
+	RuleDecode     = "decode"
+	RuleIdentifier = "identifier"
+	RuleISSN       = "issn"
+	RuleColor      = "color"
+	RulePeriod     = "period"
+	RuleDateOrder  = "date-order"
+)
+
+// Rules is the catalog of checks performed by Validate.
+var Rules = holdings.Catalog{
+	{Name: RuleXML, Severity: holdings.Error, Description: "document must be well-formed XML"},
+	{Name: RuleDecode, Severity: holdings.Error, Description: "journal must decode into the expected structure"},
+	{Name: RuleIdentifier, Severity: holdings.Warning, Description: "journal should have an ISSN or ZDB-ID"},
+	{Name: RuleISSN, Severity: holdings.Error, Description: "P_ISSN and E_ISSN must be ISSN with a valid check digit"},
+	{Name: RuleColor, Severity: holdings.Warning, Description: "colour must be one of green, yellow, yellow_red, red"},
+	{Name: RulePeriod, Severity: holdings.Error, Description: "period label must be understood, e.g. ab 1995"},
+	{Name: RuleDateOrder, Severity: holdings.Error, Description: "period must not begin after it ends"},
+}
+
+// Validate reads the remaining input and checks every journal. Problems are
+// located by the element path and the byte offset of the journal. The
+// returned error is only non-nil for I/O errors.
+func (r *Reader) Validate() (holdings.Report, error) {
+	v := holdings.Collector{Rules: Rules}
+	decoder := xml.NewDecoder(r.r)
+
+	var root string
+	var n int
+
+	for {
+		offset := decoder.InputOffset()
+		line, column := decoder.InputPos()
+		t, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*xml.SyntaxError); ok {
+				line, column := decoder.InputPos()
+				v.At = holdings.Problem{Line: line, Column: column, Offset: decoder.InputOffset()}
+				v.Add(RuleXML, "", "", err.Error())
+				return v.Report, nil
+			}
+			return v.Report, err
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root == "" {
+			root = "/" + se.Name.Local
+		}
+		if se.Name.Local != "journal" {
+			continue
+		}
+		n++
+		v.Report.Records++
+
+		path := fmt.Sprintf("%s/journal[%d]", root, n)
+		v.At = holdings.Problem{Line: line, Column: column, Offset: offset, Path: path}
+
+		var j Journal
+		if err := decoder.DecodeElement(&j, &se); err != nil {
+			v.Add(RuleDecode, "", "", err.Error())
+			continue
+		}
+		if len(j.PISSN)+len(j.EISSN)+len(j.ZDBID) == 0 {
+			v.Add(RuleIdentifier, "", j.ID, "no ISSN or ZDB-ID")
+		}
+		for i, issn := range j.PISSN {
+			if !holdings.ValidISSN(issn) {
+				v.Add(RuleISSN, fmt.Sprintf("/detail/P_ISSNs/P_ISSN[%d]", i+1), issn, "invalid ISSN")
+			}
+		}
+		for i, issn := range j.EISSN {
+			if !holdings.ValidISSN(issn) {
+				v.Add(RuleISSN, fmt.Sprintf("/detail/E_ISSNs/E_ISSN[%d]", i+1), issn, "invalid ISSN")
+			}
+		}
+		for i, p := range j.Periods {
+			pp := fmt.Sprintf("/detail/periods/period[%d]", i+1)
+			if c := p.color(j); Access(c) == "" && c != Red && c != YellowRed {
+				v.Add(RuleColor, pp, c, "unknown colour")
+			}
+			e, err := p.Entry()
+			if err != nil {
+				v.Add(RulePeriod, pp+"/label", p.Label, err.Error())
+				continue
+			}
+			if e.Begin.Date != "" && e.End.Date != "" && e.Begin.Date > e.End.Date {
+				v.Add(RuleDateOrder, pp+"/label", p.Label, "end before begin")
+			}
+		}
+	}
+	return v.Report, nil
+}
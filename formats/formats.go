@@ -0,0 +1,310 @@
+// Package formats gives access to the supported holdings file formats by
+// name, so commands do not need to know about every reader.
+package formats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/csvholdings"
+	"github.com/miku/holdings/doaj"
+	"github.com/miku/holdings/ezb"
+	"github.com/miku/holdings/google"
+	"github.com/miku/holdings/kbart"
+	"github.com/miku/holdings/marcholdings"
+	"github.com/miku/holdings/onixserials"
+	"github.com/miku/holdings/ovid"
+	"github.com/miku/holdings/sfx"
+)
+
+// Reader can load and validate a holdings file.
+type Reader interface {
+	holdings.File
+	holdings.Validator
+}
+
+// Format describes a supported holdings file format. NewReader creates a
+// reader, that handles errors according to the given policy.
+type Format struct {
+	Name      string
+	NewReader func(io.Reader, holdings.ErrorPolicy) Reader
+	Rules     holdings.Catalog
+}
+
+var registry = map[string]Format{
+	"doaj": {
+		Name: "doaj",
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := doaj.NewReader(r)
+			rr.Policy = p
+			return rr
+		},
+		Rules: doaj.Rules,
+	},
+	"ezb": {
+		Name: "ezb",
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := ezb.NewReader(r)
+			rr.Policy = p
+			return rr
+		},
+		Rules: ezb.Rules,
+	},
+	"google": {
+		Name: "google",
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := google.NewReader(r)
+			rr.Policy = p
+			return rr
+		},
+		Rules: google.Rules,
+	},
+	"kbart": {
+		Name: "kbart",
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := kbart.NewReader(r)
+			rr.Policy = p
+			return rr
+		},
+		Rules: kbart.Rules,
+	},
+	"marcholdings": {
+		Name: "marcholdings",
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := marcholdings.NewReader(r)
+			rr.Policy = p
+			return rr
+		},
+		Rules: marcholdings.Rules,
+	},
+	"onixserials": {
+		Name: "onixserials",
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := onixserials.NewReader(r)
+			rr.Policy = p
+			return rr
+		},
+		Rules: onixserials.Rules,
+	},
+	"ovid": {
+		Name: "ovid",
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := ovid.NewReader(r)
+			rr.Policy = p
+			return rr
+		},
+		Rules: ovid.Rules,
+	},
+	"sfx": {
+		Name: "sfx",
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := sfx.NewReader(r)
+			rr.Policy = p
+			return rr
+		},
+		Rules: sfx.Rules,
+	},
+}
+
+// Register adds a format or replaces a format of the same name.
+func Register(f Format) {
+	registry[f.Name] = f
+}
+
+// CSV returns a format for delimited files, described by a mapping config.
+// Since every vendor needs its own config, CSV formats are not registered by
+// default.
+func CSV(name string, c csvholdings.Config) Format {
+	return Format{
+		Name: name,
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := csvholdings.NewReader(r, c)
+			rr.Policy = p
+			return rr
+		},
+		Rules: csvholdings.Rules,
+	}
+}
+
+// MARCHoldings returns the marcholdings format, which additionally keys
+// entries by the ISSNs, that links has for the bibliographic record in 004.
+func MARCHoldings(links marcholdings.Links) Format {
+	return Format{
+		Name: "marcholdings",
+		NewReader: func(r io.Reader, p holdings.ErrorPolicy) Reader {
+			rr := marcholdings.NewReader(r)
+			rr.Policy = p
+			rr.Linkage = links.ISSNs
+			return rr
+		},
+		Rules: marcholdings.Rules,
+	}
+}
+
+// RegisterLinkage reads a linkage file, as understood by
+// marcholdings.ReadLinks, and registers the marcholdings format with it.
+func RegisterLinkage(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	links, err := marcholdings.ReadLinks(f)
+	if err != nil {
+		return err
+	}
+	Register(MARCHoldings(links))
+	return nil
+}
+
+// Names returns the names of all supported formats.
+func Names() []string {
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns a format by name.
+func Lookup(name string) (Format, error) {
+	f, ok := registry[name]
+	if !ok {
+		return f, fmt.Errorf("unknown format: %s", name)
+	}
+	return f, nil
+}
+
+// NewReader returns a reader for a named format, which uses the given error
+// policy.
+func NewReader(name string, r io.Reader, p holdings.ErrorPolicy) (Reader, error) {
+	f, err := Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.NewReader(r, p), nil
+}
+
+// ReadFile reads a holdings file of a named format.
+func ReadFile(filename, format string, p holdings.ErrorPolicy) (holdings.Entries, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r, err := NewReader(format, file, p)
+	if err != nil {
+		return nil, err
+	}
+	return r.ReadAll()
+}
+
+// Source is a holdings file of a tenant.
+type Source struct {
+	File   string `json:"file"`
+	Format string `json:"format"`
+}
+
+// TenantsConfig lists the holdings files per institution, e.g.
+//
+//	{"DE-15": [{"file": "de15/springer.tsv", "format": "kbart"}]}
+type TenantsConfig map[holdings.ISIL][]Source
+
+// LoadTenants reads the files of all institutions. Relative file names are
+// resolved against dir. Licenses from several files of an institution are
+// combined.
+func (c TenantsConfig) LoadTenants(dir string, p holdings.ErrorPolicy) (holdings.Tenants, error) {
+	tenants := make(holdings.Tenants)
+	for isil, sources := range c {
+		entries := make(holdings.Entries)
+		for _, s := range sources {
+			filename := s.File
+			if !filepath.IsAbs(filename) {
+				filename = filepath.Join(dir, filename)
+			}
+			e, err := ReadFile(filename, s.Format, p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", isil, filename, err)
+			}
+			for id, licenses := range e {
+				entries[id] = append(entries[id], licenses...)
+			}
+		}
+		tenants[isil] = entries
+	}
+	return tenants, nil
+}
+
+// ReadTenantsConfig reads a JSON tenants config.
+func ReadTenantsConfig(r io.Reader) (TenantsConfig, error) {
+	var c TenantsConfig
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// TenantsDir returns the config for a directory with a subdirectory per
+// institution, named by ISIL. The extension of a file names its format, e.g.
+// DE-15/springer.kbart or DE-15/scholar.google. Hidden files are ignored.
+func TenantsDir(dir string) (TenantsConfig, error) {
+	c := make(TenantsConfig)
+	institutions, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, inst := range institutions {
+		if !inst.IsDir() || strings.HasPrefix(inst.Name(), ".") {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, inst.Name()))
+		if err != nil {
+			return nil, err
+		}
+		isil := holdings.ISIL(inst.Name())
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			format := strings.TrimPrefix(filepath.Ext(f.Name()), ".")
+			if _, err := Lookup(format); err != nil {
+				return nil, fmt.Errorf("%s: %w", filepath.Join(inst.Name(), f.Name()), err)
+			}
+			c[isil] = append(c[isil], Source{File: filepath.Join(inst.Name(), f.Name()), Format: format})
+		}
+	}
+	return c, nil
+}
+
+// LoadTenants loads tenants from a directory, see TenantsDir, or from a JSON
+// config file, see TenantsConfig.
+func LoadTenants(path string, p holdings.ErrorPolicy) (holdings.Tenants, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		c, err := TenantsDir(path)
+		if err != nil {
+			return nil, err
+		}
+		return c.LoadTenants(path, p)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	c, err := ReadTenantsConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	return c.LoadTenants(filepath.Dir(path), p)
+}
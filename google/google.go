@@ -3,19 +3,56 @@ package google
 import (
 	"bufio"
 	"encoding/xml"
+	"errors"
 	"io"
 	"time"
 
 	"github.com/miku/holdings"
+	"github.com/miku/holdings/internal/snippet"
 )
 
-// Item is the main google scholar holdings container.
+// Item is the main google scholar holdings container. An item may list
+// several identifiers, e.g. print and electronic ISSN.
 type Item struct {
-	Title string     `xml:"title"`
-	ISSN  string     `xml:"issn"`
+	Title string `xml:"title"`
+	// ISSN is the first ISSN of the item.
+	//
+	// Deprecated: Use ISSNs, which has all of them.
+	ISSN  string     `xml:"-"`
+	ISSNs []string   `xml:"issn"`
+	EISSN []string   `xml:"eissn"`
+	ISBN  []string   `xml:"isbn"`
 	Covs  []Coverage `xml:"coverage"`
 }
 
+// Identifiers returns all identifiers of an item. Valid ISBNs are returned
+// as ISBN-13, like holdings keep them.
+func (item Item) Identifiers() []string {
+	var ids []string
+	if item.ISSN != "" && !contains(item.ISSNs, item.ISSN) {
+		ids = append(ids, item.ISSN)
+	}
+	ids = append(ids, item.ISSNs...)
+	ids = append(ids, item.EISSN...)
+	for _, id := range item.ISBN {
+		if isbn, ok := holdings.NormalizeISBN(id); ok {
+			id = isbn
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// contains returns true, if s is in ss.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // Coverage contains coverage information for an item.
 type Coverage struct {
 	FromYear         string `xml:"from>year"`
@@ -28,68 +65,125 @@ type Coverage struct {
 	DaysNotAvailable int    `xml:"embargo>days_not_available"`
 }
 
+// Reader reads Google Scholar holdings XML. Items can be read one at a time
+// with Read or all at once with ReadAll. By default, ReadAll skips broken
+// items and returns their errors as holdings.ParseError.
 type Reader struct {
-	r io.Reader
+	r       io.Reader
+	sr      *snippet.Reader
+	decoder *xml.Decoder
+	Policy  holdings.ErrorPolicy
 }
 
 func NewReader(r io.Reader) *Reader {
-	return &Reader{r: bufio.NewReader(r)}
+	return &Reader{
+		r:      bufio.NewReader(r),
+		Policy: holdings.ErrorPolicy{Action: holdings.SkipAndCollect},
+	}
 }
 
+// parseEmbargo turns days_not_available into a moving wall. Like in the
+// other formats, the duration is negative, since it is added to the current
+// time to find the boundary.
 func parseEmbargo(i int) time.Duration {
 	var d time.Duration
-	if i == 0 {
+	if i <= 0 {
 		return d
 	}
-	return time.Duration(i*24) * time.Hour
+	return time.Duration(-i) * holdings.Day
 }
 
-func (r Reader) ReadAll() (holdings.Entries, error) {
-	entries := make(holdings.Entries)
-	decoder := xml.NewDecoder(r.r)
-	var tag string
+// init sets up the decoder, that Read and Validate share.
+func (r *Reader) init() {
+	if r.decoder == nil {
+		r.sr = snippet.NewReader(r.r)
+		r.decoder = xml.NewDecoder(r.sr)
+	}
+}
 
+// Read returns the next item. An item, that cannot be decoded, results in a
+// *holdings.RecordError and reading can continue. At the end of the input
+// io.EOF is returned.
+func (r *Reader) Read() (Item, error) {
+	var item Item
+	r.init()
 	for {
+		line, column := r.decoder.InputPos()
+		r.sr.Mark(r.decoder.InputOffset())
 
-		t, err := decoder.Token()
+		t, err := r.decoder.Token()
+		if err != nil {
+			return item, err
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "item" {
+			continue
+		}
+		if err := r.decoder.DecodeElement(&item, &se); err != nil {
+			rerr := &holdings.RecordError{
+				Line:    line,
+				Column:  column,
+				Snippet: r.sr.String(),
+				Err:     err,
+			}
+			if ids := item.Identifiers(); len(ids) > 0 {
+				rerr.Record = ids[0]
+			}
+			return item, rerr
+		}
+		if len(item.ISSNs) > 0 {
+			item.ISSN = item.ISSNs[0]
+		}
+		return item, nil
+	}
+}
+
+// ReadAll reads the remaining items.
+func (r *Reader) ReadAll() (holdings.Entries, error) {
+	entries := make(holdings.Entries)
+
+	// collect errors, if the policy says so
+	perr := holdings.ParseError{}
+
+	for {
+		item, err := r.Read()
 		if err == io.EOF {
 			break
 		}
+		var rerr *holdings.RecordError
+		if errors.As(err, &rerr) {
+			if err := r.Policy.Handle(&perr, rerr); err != nil {
+				return entries, err
+			}
+			continue
+		}
 		if err != nil {
 			return entries, err
 		}
-		if t == nil {
-			break
-		}
 
-		switch se := t.(type) {
-		case xml.StartElement:
-			tag = se.Name.Local
-			if tag == "item" {
-				var item Item
-				err := decoder.DecodeElement(&item, &se)
-				if err != nil {
-					return entries, err
-				}
-
-				for _, cov := range item.Covs {
-					entry := holdings.Entry{
-						Begin: holdings.Signature{
-							Date:   cov.FromYear,
-							Volume: cov.FromVolume,
-							Issue:  cov.FromIssue,
-						},
-						End: holdings.Signature{
-							Date:   cov.ToYear,
-							Volume: cov.ToVolume,
-							Issue:  cov.ToIssue,
-						},
-						Embargo: parseEmbargo(cov.DaysNotAvailable),
-					}
-					entries[item.ISSN] = append(entries[item.ISSN], entry)
-				}
+		for _, cov := range item.Covs {
+			entry := holdings.Entry{
+				Begin: holdings.Signature{
+					Date:   cov.FromYear,
+					Volume: cov.FromVolume,
+					Issue:  cov.FromIssue,
+				},
+				End: holdings.Signature{
+					Date:   cov.ToYear,
+					Volume: cov.ToVolume,
+					Issue:  cov.ToIssue,
+				},
+				Embargo: parseEmbargo(cov.DaysNotAvailable),
+				Title:   item.Title,
+				Comment: cov.Comment,
+			}
+			for _, id := range item.Identifiers() {
+				entries[id] = append(entries[id], entry)
 			}
 		}
 	}
+	if len(perr.Errors) > 0 {
+		return entries, perr
+	}
 	return entries, nil
 }
@@ -0,0 +1,72 @@
+package google
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/miku/holdings"
+)
+
+const doc = `<institutional_holdings>
+<item type="electronic">
+  <title>A</title>
+  <issn>0006-2499</issn>
+  <issn>1613-4141</issn>
+  <coverage><from><year>1990</year></from><comment>Backfile</comment></coverage>
+</item>
+<item type="electronic">
+  <title>B</title>
+  <issn>2434-561X</issn>
+  <coverage><embargo><days_not_available>many</days_not_available></embargo></coverage>
+</item>
+<item type="electronic">
+  <title>C</title>
+  <isbn>9783161484100</isbn>
+  <coverage><from><year>2001</year></from></coverage>
+</item>
+</institutional_holdings>`
+
+func TestRead(t *testing.T) {
+	r := NewReader(strings.NewReader(doc))
+	var titles []string
+	for {
+		item, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var rerr *holdings.RecordError
+		if errors.As(err, &rerr) {
+			if rerr.Line != 8 || rerr.Record != "2434-561X" {
+				t.Errorf("got line %d, record %q, want 8, 2434-561X", rerr.Line, rerr.Record)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, item.Title)
+	}
+	if strings.Join(titles, " ") != "A C" {
+		t.Errorf("got titles %v, want A C", titles)
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	entries, err := NewReader(strings.NewReader(doc)).ReadAll()
+
+	var perr holdings.ParseError
+	if !errors.As(err, &perr) || len(perr.Errors) != 1 {
+		t.Fatalf("ReadAll got %v, want a single collected error", err)
+	}
+	for _, id := range []string{"0006-2499", "1613-4141", "9783161484100"} {
+		if len(entries.Licenses(id)) != 1 {
+			t.Errorf("got %d licenses for %s, want 1", len(entries.Licenses(id)), id)
+		}
+	}
+	entry := entries.Licenses("1613-4141")[0].(holdings.Entry)
+	if entry.Title != "A" || entry.Comment != "Backfile" {
+		t.Errorf("got title %q, comment %q", entry.Title, entry.Comment)
+	}
+}
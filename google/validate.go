@@ -0,0 +1,114 @@
+package google
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/miku/holdings"
+)
+
+// Rule names, see Rules for a description.
+const (
+	RuleXML       = "xml"
+	RuleDecode    = "decode"
+	RuleISSN      = "issn"
+	RuleISBN      = "isbn"
+	RuleEmbargo   = "embargo"
+	RuleDateOrder = "date-order"
+)
+
+// Rules is the catalog of checks performed by Validate.
+var Rules = holdings.Catalog{
+	{Name: RuleXML, Severity: holdings.Error, Description: "document must be well-formed XML"},
+	{Name: RuleDecode, Severity: holdings.Error, Description: "item must decode into the expected structure"},
+	{Name: RuleISSN, Severity: holdings.Error, Description: "issn and eissn must be ISSN with a valid check digit"},
+	{Name: RuleISBN, Severity: holdings.Error, Description: "isbn must be ISBN-10 or ISBN-13 with a valid check digit"},
+	{Name: RuleEmbargo, Severity: holdings.Error, Description: "days_not_available must not be negative"},
+	{Name: RuleDateOrder, Severity: holdings.Error, Description: "coverage must not begin after it ends"},
+}
+
+// Validate reads the remaining input and checks every item. Problems are
+// located by the element path and the byte offset of the item. The returned
+// error is only non-nil for I/O errors.
+func (r *Reader) Validate() (holdings.Report, error) {
+	v := holdings.Collector{Rules: Rules}
+	r.init()
+	decoder := r.decoder
+
+	var root string
+	var n int
+
+	for {
+		offset := decoder.InputOffset()
+		line, column := decoder.InputPos()
+		t, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*xml.SyntaxError); ok {
+				line, column := decoder.InputPos()
+				v.At = holdings.Problem{Line: line, Column: column, Offset: decoder.InputOffset()}
+				v.Add(RuleXML, "", "", err.Error())
+				return v.Report, nil
+			}
+			return v.Report, err
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root == "" {
+			root = "/" + se.Name.Local
+		}
+		if se.Name.Local != "item" {
+			continue
+		}
+		n++
+		v.Report.Records++
+
+		path := fmt.Sprintf("%s/item[%d]", root, n)
+		v.At = holdings.Problem{Line: line, Column: column, Offset: offset, Path: path}
+
+		var item Item
+		if err := decoder.DecodeElement(&item, &se); err != nil {
+			v.Add(RuleDecode, "", "", err.Error())
+			continue
+		}
+		for i, issn := range item.ISSNs {
+			if !holdings.ValidISSN(issn) {
+				v.Add(RuleISSN, fmt.Sprintf("/issn[%d]", i+1), issn, "invalid ISSN")
+			}
+		}
+		for i, issn := range item.EISSN {
+			if !holdings.ValidISSN(issn) {
+				v.Add(RuleISSN, fmt.Sprintf("/eissn[%d]", i+1), issn, "invalid ISSN")
+			}
+		}
+		for i, isbn := range item.ISBN {
+			if _, ok := holdings.NormalizeISBN(isbn); !ok {
+				v.Add(RuleISBN, fmt.Sprintf("/isbn[%d]", i+1), isbn, "invalid ISBN")
+			}
+		}
+		for i, cov := range item.Covs {
+			p := fmt.Sprintf("/coverage[%d]", i+1)
+			if cov.DaysNotAvailable < 0 {
+				v.Add(RuleEmbargo, p+"/embargo/days_not_available",
+					strconv.Itoa(cov.DaysNotAvailable), "negative embargo")
+			}
+			if cov.FromYear != "" && cov.ToYear != "" && cov.FromYear > cov.ToYear {
+				v.Add(RuleDateOrder, p+"/to/year", cov.ToYear, "end before begin "+cov.FromYear)
+			}
+			if cov.FromVolume != "" && cov.ToVolume != "" {
+				from, to := holdings.Signature{Volume: cov.FromVolume}, holdings.Signature{Volume: cov.ToVolume}
+				if from.VolumeInt() > to.VolumeInt() {
+					v.Add(RuleDateOrder, p+"/to/volume", cov.ToVolume, "end before begin "+cov.FromVolume)
+				}
+			}
+		}
+	}
+	return v.Report, nil
+}
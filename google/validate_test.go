@@ -0,0 +1,70 @@
+package google
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	doc := `<institutional_holdings>
+<item type="electronic">
+  <title>A</title>
+  <issn>0006-2499</issn>
+  <eissn>0006-2490</eissn>
+  <coverage><from><year>2010</year></from><to><year>2009</year></to></coverage>
+</item>
+<item type="electronic">
+  <title>B</title>
+  <isbn>978-3-16-148410-0</isbn>
+  <isbn>3-16-148410-1</isbn>
+  <coverage><embargo><days_not_available>-1</days_not_available></embargo></coverage>
+</item>
+<item type="electronic"><coverage><embargo><days_not_available>many</days_not_available></embargo></coverage></item>
+</institutional_holdings>`
+
+	report, err := NewReader(strings.NewReader(doc)).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 3 {
+		t.Errorf("got %d records, want 3", report.Records)
+	}
+	var want = []struct {
+		rule string
+		path string
+	}{
+		{RuleISSN, "/institutional_holdings/item[1]/eissn[1]"},
+		{RuleDateOrder, "/institutional_holdings/item[1]/coverage[1]/to/year"},
+		{RuleISBN, "/institutional_holdings/item[2]/isbn[2]"},
+		{RuleEmbargo, "/institutional_holdings/item[2]/coverage[1]/embargo/days_not_available"},
+		{RuleDecode, "/institutional_holdings/item[3]"},
+	}
+	if len(report.Problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %+v", len(report.Problems), len(want), report.Problems)
+	}
+	for i, w := range want {
+		p := report.Problems[i]
+		if p.Rule != w.rule || p.Path != w.path {
+			t.Errorf("got %s at %s, want %s at %s", p.Rule, p.Path, w.rule, w.path)
+		}
+	}
+}
+
+func TestValidateAfterRead(t *testing.T) {
+	r := NewReader(strings.NewReader(doc))
+	item, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ISSN != "0006-2499" {
+		t.Errorf("got ISSN %q, want 0006-2499", item.ISSN)
+	}
+	// validation continues after the item already read
+	report, err := r.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 2 {
+		t.Errorf("got %d records, want 2", report.Records)
+	}
+}
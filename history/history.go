@@ -0,0 +1,120 @@
+// Package history expands holdings lookups across title changes. Journals
+// change titles and ISSNs over time; a record carrying the ISSN of a
+// predecessor should match a license of a successor, if the license covers
+// the record. Coverage is still checked by the licenses, so expanding the
+// lookup does not grant access to years a package does not cover.
+package history
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/miku/holdings"
+)
+
+// Graph relates identifiers of journals. Aliases are identifiers of the same
+// journal, e.g. print and online ISSN; other edges connect a journal with
+// its predecessors and successors.
+type Graph struct {
+	edges map[string]map[string]bool
+}
+
+func NewGraph() *Graph {
+	return &Graph{edges: make(map[string]map[string]bool)}
+}
+
+// link adds an undirected edge.
+func (g *Graph) link(a, b string) {
+	if a == "" || b == "" || a == b {
+		return
+	}
+	for _, p := range [][2]string{{a, b}, {b, a}} {
+		if g.edges[p[0]] == nil {
+			g.edges[p[0]] = make(map[string]bool)
+		}
+		g.edges[p[0]][p[1]] = true
+	}
+}
+
+// Alias records, that two identifiers belong to the same journal.
+func (g *Graph) Alias(a, b string) {
+	g.link(a, b)
+}
+
+// Add records, that succeeding continues preceding. Lookups follow title
+// changes in both directions.
+func (g *Graph) Add(preceding, succeeding string) {
+	g.link(preceding, succeeding)
+}
+
+// Related returns all identifiers connected to id by aliases or title
+// changes in either direction, sorted and without id itself.
+func (g *Graph) Related(id string) []string {
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	var related []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for next := range g.edges[current] {
+			if seen[next] {
+				continue
+			}
+			seen[next] = true
+			related = append(related, next)
+			queue = append(queue, next)
+		}
+	}
+	sort.Strings(related)
+	return related
+}
+
+// Len returns the number of identifiers in the graph.
+func (g *Graph) Len() int {
+	return len(g.edges)
+}
+
+// Holdings decorates holdings, so lookups return the licenses of all
+// identifiers related by the graph.
+type Holdings struct {
+	Holdings holdings.Holdings
+	Graph    *Graph
+}
+
+// Licenses returns the licenses of id, followed by the licenses of related
+// identifiers. Holding files usually list a license under both print and
+// online ISSN, so a license is returned only once.
+func (h Holdings) Licenses(id string) []holdings.License {
+	licenses := appendDistinct(nil, h.Holdings.Licenses(id))
+	if h.Graph == nil {
+		return licenses
+	}
+	for _, related := range h.Graph.Related(id) {
+		licenses = appendDistinct(licenses, h.Holdings.Licenses(related))
+	}
+	return licenses
+}
+
+// appendDistinct appends the licenses, that are not already in dst.
+// Licenses of types, that cannot be compared, are always appended.
+func appendDistinct(dst, licenses []holdings.License) []holdings.License {
+	for _, l := range licenses {
+		if !contains(dst, l) {
+			dst = append(dst, l)
+		}
+	}
+	return dst
+}
+
+// contains returns true, if l is in licenses.
+func contains(licenses []holdings.License, l holdings.License) bool {
+	if l == nil || !reflect.TypeOf(l).Comparable() {
+		return false
+	}
+	for _, x := range licenses {
+		if x == l {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package history
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miku/holdings"
+)
+
+func TestHoldings(t *testing.T) {
+	g := NewGraph()
+	g.Alias("1111-1111", "2222-2222")
+	g.Add("0000-0000", "1111-1111")
+
+	successor := holdings.Entry{Begin: holdings.Signature{Date: "1950"}}
+	entries := holdings.Entries{"2222-2222": []holdings.License{successor}}
+	h := Holdings{Holdings: entries, Graph: g}
+
+	licenses := h.Licenses("0000-0000")
+	if !reflect.DeepEqual(licenses, []holdings.License{successor}) {
+		t.Fatalf("Licenses: got %v", licenses)
+	}
+	// the license still checks coverage
+	if err := licenses[0].Covers(holdings.Signature{Date: "1940"}); err == nil {
+		t.Error("Covers: expected record before coverage to fail")
+	}
+	if len(entries["2222-2222"]) != 1 {
+		t.Error("Licenses must not modify the decorated holdings")
+	}
+	if got := h.Licenses("9999-9999"); len(got) != 0 {
+		t.Errorf("Licenses: got %v for unrelated identifier", got)
+	}
+}
+
+func TestHoldingsAliases(t *testing.T) {
+	g := NewGraph()
+	g.Alias("1111-1111", "2222-2222")
+
+	// a holding file lists the license under print and online ISSN
+	license := holdings.Entry{Begin: holdings.Signature{Date: "1950"}}
+	other := holdings.Entry{Begin: holdings.Signature{Date: "1990"}}
+	entries := holdings.Entries{
+		"1111-1111": []holdings.License{license},
+		"2222-2222": []holdings.License{license, other},
+	}
+	h := Holdings{Holdings: entries, Graph: g}
+	want := []holdings.License{license, other}
+	if got := h.Licenses("1111-1111"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Licenses: got %v, want %v", got, want)
+	}
+}
+
+func TestReadMARC(t *testing.T) {
+	doc := `<collection>
+<record>
+  <datafield tag="016" ind1="7" ind2=" "><subfield code="a">123-4</subfield><subfield code="2">DE-600</subfield></datafield>
+  <datafield tag="022" ind1=" " ind2=" "><subfield code="a">1111-1111</subfield></datafield>
+  <datafield tag="780" ind1="0" ind2="0"><subfield code="x">0000-0000</subfield></datafield>
+  <datafield tag="785" ind1="0" ind2="7"><subfield code="w">(DE-600)999-9</subfield></datafield>
+</record>
+</collection>`
+	g, err := ReadMARC(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1111-1111", holdings.ZDBKey("123-4")}
+	if got := g.Related("0000-0000"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Related: got %v, want %v", got, want)
+	}
+}
+
+func TestReadKBART(t *testing.T) {
+	doc := "publication_title\tprint_identifier\tonline_identifier\ttitle_id\tpreceding_publication_title_id\n" +
+		"Old\t0000-0000\t\told\t\n" +
+		"New\t1111-1111\t2222-2222\tnew\told\n"
+	g, err := ReadKBART(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1111-1111", "2222-2222"}
+	if got := g.Related("0000-0000"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Related: got %v, want %v", got, want)
+	}
+}
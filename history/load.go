@@ -0,0 +1,203 @@
+package history
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/marc"
+)
+
+// zdbPattern matches ZDB numbers in linking fields, e.g. (DE-600)2345678-9
+var zdbPattern = regexp.MustCompile(`^\(DE-600\)\s*(\S+)$`)
+
+// linkedIDs returns the ISSNs and ZDB numbers of a linking entry field.
+func linkedIDs(f marc.Field) []string {
+	var ids []string
+	for _, x := range f.SubfieldValues("x") {
+		if issn, ok := holdings.NormalizeISSN(x); ok {
+			ids = append(ids, issn)
+		}
+	}
+	for _, w := range f.SubfieldValues("w") {
+		if ms := zdbPattern.FindStringSubmatch(strings.TrimSpace(w)); ms != nil {
+			ids = append(ids, holdings.ZDBKey(ms[1]))
+		}
+	}
+	return ids
+}
+
+// recordReader is implemented by the ISO 2709 and the MARCXML reader.
+type recordReader interface {
+	Read() (marc.Record, error)
+}
+
+// ReadMARC loads title relationships from bibliographic MARC records, e.g.
+// a ZDB export, in ISO 2709 or MARCXML. A journal is identified by its ISSNs
+// in 022 and 776 and by its ZDB number in 016, which is keyed like
+// holdings.ZDBKey. Preceding and succeeding entries are taken from 780 and 785;
+// only continuations and supersessions count, mergers, absorptions and
+// splits do not.
+func ReadMARC(r io.Reader) (*Graph, error) {
+	g := NewGraph()
+	br := bufio.NewReader(r)
+	var rr recordReader = marc.NewReader(br)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			break
+		}
+		if b[0] == '<' {
+			rr = marc.NewXMLReader(br)
+			break
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			break
+		}
+		br.ReadByte()
+	}
+	for {
+		record, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*xml.SyntaxError); ok || err == io.ErrUnexpectedEOF {
+				return g, err
+			}
+			continue
+		}
+		var ids []string
+		for _, f := range record.Get("022") {
+			if issn, ok := holdings.NormalizeISSN(f.Subfield("a")); ok {
+				ids = append(ids, issn)
+			}
+		}
+		for _, f := range record.Get("016") {
+			if f.Subfield("2") == "DE-600" && f.Subfield("a") != "" {
+				ids = append(ids, holdings.ZDBKey(f.Subfield("a")))
+			}
+		}
+		for _, f := range record.Get("776") {
+			ids = append(ids, linkedIDs(f)...)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		for _, id := range ids[1:] {
+			g.Alias(ids[0], id)
+		}
+		for _, f := range record.Get("780") {
+			if !strings.Contains("0123", blank(f.Ind2)) {
+				continue
+			}
+			for _, id := range linkedIDs(f) {
+				g.Add(id, ids[0])
+			}
+		}
+		for _, f := range record.Get("785") {
+			if !strings.Contains("0123", blank(f.Ind2)) {
+				continue
+			}
+			for _, id := range linkedIDs(f) {
+				g.Add(ids[0], id)
+			}
+		}
+	}
+	return g, nil
+}
+
+// blank returns an indicator or a blank, which no relation type matches.
+func blank(ind string) string {
+	if ind == "" {
+		return " "
+	}
+	return ind
+}
+
+// KBART Phase II positions, if a file has no header row.
+const (
+	columnPrint     = 1
+	columnOnline    = 2
+	columnTitleID   = 11
+	columnPreceding = 23
+)
+
+// ReadKBART loads title relationships from the
+// preceding_publication_title_id column of a KBART file. The column refers
+// to the title_id of the predecessor, which is resolved to its ISSNs, if the
+// predecessor is listed in the file; otherwise the value is used as is. A
+// header row is used to find the columns, without one the Phase II order is
+// assumed.
+func ReadKBART(r io.Reader) (*Graph, error) {
+	g := NewGraph()
+	br := bufio.NewReader(r)
+
+	index := map[string]int{
+		"print_identifier":               columnPrint,
+		"online_identifier":              columnOnline,
+		"title_id":                       columnTitleID,
+		"preceding_publication_title_id": columnPreceding,
+	}
+	get := func(record []string, name string) string {
+		if i := index[name]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	titles := make(map[string][]string) // title_id to ISSNs
+	var preceding [][2]string           // title_id or ISSN, ISSN
+
+	for n := 0; ; n++ {
+		line, err := br.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			record := strings.Split(line, "\t")
+			if n == 0 && strings.TrimSpace(record[0]) == "publication_title" {
+				for i, name := range record {
+					index[strings.TrimSpace(name)] = i
+				}
+				continue
+			}
+			var ids []string
+			for _, name := range []string{"print_identifier", "online_identifier"} {
+				if issn, ok := holdings.NormalizeISSN(get(record, name)); ok {
+					ids = append(ids, issn)
+				}
+			}
+			if len(ids) > 0 {
+				for _, id := range ids[1:] {
+					g.Alias(ids[0], id)
+				}
+				if tid := get(record, "title_id"); tid != "" {
+					titles[tid] = append(titles[tid], ids...)
+				}
+				if p := get(record, "preceding_publication_title_id"); p != "" {
+					preceding = append(preceding, [2]string{p, ids[0]})
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return g, err
+		}
+	}
+	for _, p := range preceding {
+		ids, ok := titles[p[0]]
+		if !ok {
+			ids = []string{p[0]}
+			if issn, ok := holdings.NormalizeISSN(p[0]); ok {
+				ids = []string{issn}
+			}
+		}
+		for _, id := range ids {
+			g.Add(id, p[1])
+		}
+	}
+	return g, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,20 +14,68 @@ var (
 	ErrAfterCoverageInterval  = errors.New("after coverage interval")
 	ErrMissingValues          = errors.New("missing values")
 	ErrMovingWall             = errors.New("moving wall")
+	ErrTooManyErrors          = errors.New("too many errors")
 )
 
 var (
 	intPattern = regexp.MustCompile("[0-9]+")
 )
 
+// Units for moving walls, which are shared by all formats, so equivalent
+// embargoes restrict the same records.
+const (
+	Day   = 24 * time.Hour
+	Month = 30 * Day
+	Year  = 365 * Day
+)
+
 // ParseError collects unmarshal errors.
 type ParseError struct {
 	Errors []error
 }
 
-// Error returns the number of errors encountered.
+// Error returns the number of errors encountered and the first error.
 func (e ParseError) Error() string {
-	return fmt.Sprintf("%d error(s) detected", len(e.Errors))
+	if len(e.Errors) == 0 {
+		return "0 error(s) detected"
+	}
+	return fmt.Sprintf("%d error(s) detected, first: %s", len(e.Errors), e.Errors[0])
+}
+
+// Unwrap gives errors.Is and errors.As access to the collected errors.
+func (e ParseError) Unwrap() []error {
+	return e.Errors
+}
+
+// RecordError is an error, that occurred while reading a single record. Line
+// and column are 1-based and zero if unknown.
+type RecordError struct {
+	Line    int
+	Column  int
+	Record  string
+	Snippet string
+	Err     error
+}
+
+// Error reports position and record identifier along with the error.
+func (e *RecordError) Error() string {
+	var s string
+	if e.Line > 0 {
+		s = fmt.Sprintf("line %d", e.Line)
+		if e.Column > 0 {
+			s = fmt.Sprintf("%s, column %d", s, e.Column)
+		}
+		s += ": "
+	}
+	if e.Record != "" {
+		s = fmt.Sprintf("%srecord %s: ", s, e.Record)
+	}
+	return s + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *RecordError) Unwrap() error {
+	return e.Err
 }
 
 // Holdings can return a list of licenses for a given ISSN.
@@ -51,9 +100,74 @@ type File interface {
 // Holdings.
 type Entries map[string][]License
 
-// Licenses make Entries fulfill the holdings interface.
+// Licenses make Entries fulfill the holdings interface. ISBNs, that are not
+// found as given, are looked up in normalized ISBN-13 form.
 func (e Entries) Licenses(issn string) []License {
-	return e[issn]
+	if licenses, ok := e[issn]; ok {
+		return licenses
+	}
+	if isbn, ok := NormalizeISBN(issn); ok {
+		return e[isbn]
+	}
+	return nil
+}
+
+// Sources combines holdings, e.g. subscriptions and open access. Licenses
+// are returned in the order of the sources.
+type Sources []Holdings
+
+// Licenses returns the licenses of all sources.
+func (s Sources) Licenses(id string) []License {
+	var licenses []License
+	for _, h := range s {
+		licenses = append(licenses, h.Licenses(id)...)
+	}
+	return licenses
+}
+
+// AccessType tells, whether content is available to everyone or only to
+// licensees.
+type AccessType string
+
+const (
+	FreeAccess     AccessType = "free"
+	LicensedAccess AccessType = "licensed"
+)
+
+// AccessOf returns the access type of a license, if it tells. Only Entry
+// and Book carry an access type.
+func AccessOf(l License) AccessType {
+	switch l := l.(type) {
+	case Entry:
+		return l.Access
+	case Book:
+		return l.Access
+	}
+	return ""
+}
+
+// TitleOf returns the title of a license, if it tells. Only Entry and Book
+// carry a title.
+func TitleOf(l License) string {
+	switch l := l.(type) {
+	case Entry:
+		return l.Title
+	case Book:
+		return l.Title
+	}
+	return ""
+}
+
+// URLOf returns the title URL of a license, if it tells. Only Entry and Book
+// carry a title URL.
+func URLOf(l License) string {
+	switch l := l.(type) {
+	case Entry:
+		return l.URL
+	case Book:
+		return l.URL
+	}
+	return ""
 }
 
 // Entry is a reduced holding file entry. Usually, moving wall allow the
@@ -64,6 +178,19 @@ type Entry struct {
 	End                    Signature
 	Embargo                time.Duration
 	EmbargoDisallowEarlier bool
+	// Open marks an entry, that is not restricted by date, although it has
+	// no date bounds, e.g. a license for all issues, that a holding file
+	// states explicitly. Without it, Covers needs a date bound.
+	Open bool
+	// Status is a format specific license status, e.g. subscribed.
+	Status string
+	// Title and Comment are informational and taken from the holding file.
+	Title   string
+	Comment string
+	// Access is the access type, if the holding file tells.
+	Access AccessType
+	// URL is the title URL, if the holding file tells.
+	URL string
 }
 
 // TimeRestricted returns an error, if the given time falls within the moving
@@ -109,9 +236,10 @@ func (e Entry) Covers(s Signature) error {
 }
 
 // compareYear returns an error, if both values are defined and disagree, or
-// if too few values are defined to do a sane comparison.
+// if too few values are defined to do a sane comparison. An open entry
+// covers any date.
 func (e Entry) compareDate(s Signature) error {
-	if s.Date == "" || (e.Begin.Date == "" && e.End.Date == "") {
+	if s.Date == "" || (e.Begin.Date == "" && e.End.Date == "" && !e.Open) {
 		return ErrMissingValues
 	}
 	if e.Begin.Date != "" {
@@ -127,6 +255,20 @@ func (e Entry) compareDate(s Signature) error {
 	return nil
 }
 
+// CompareDates compares two dates of the form YYYY, YYYY-MM or YYYY-MM-DD
+// on their common prefix, so a date is equal to any date of a different
+// precision, that shares its year or month, e.g. 2009 and 2009-06. It
+// returns -1, 0 or 1. Validators use it to check the order of begin and end
+// dates, Covers compares dates as given.
+func CompareDates(a, b string) int {
+	if len(a) > len(b) {
+		a = a[:len(b)]
+	} else {
+		b = b[:len(a)]
+	}
+	return strings.Compare(a, b)
+}
+
 // compareVolume returns an error, if both values are defined and disagree,
 // otherwise we assume there is no error.
 func (e Entry) compareVolume(s Signature) error {
@@ -171,9 +313,11 @@ func (e Entry) compareIssue(s Signature) error {
 // won't.
 type Signature struct {
 	// Date is often just a year, but sometime also an ISO-8601 date.
-	Date   string
-	Volume string
-	Issue  string
+	Date   string `json:"date,omitempty"`
+	Volume string `json:"volume,omitempty"`
+	Issue  string `json:"issue,omitempty"`
+	// Edition is only used for books, e.g. 2 or 2nd ed.
+	Edition string `json:"edition,omitempty"`
 }
 
 // VolumeInt returns the Volume in a best effort manner.
@@ -191,6 +191,48 @@ func TestEntryCovers(t *testing.T) {
 			s:   Signature{Date: "2009", Volume: "100 Total Vol 6", Issue: ""},
 			err: ErrAfterCoverageInterval,
 		},
+		{
+			description: "fail, full date before a begin month",
+			entry: Entry{
+				Begin: Signature{Date: "2009-06", Volume: "", Issue: ""},
+				End:   Signature{Date: "", Volume: "", Issue: ""}},
+			s:   Signature{Date: "2009-05-31", Volume: "", Issue: ""},
+			err: ErrBeforeCoverageInterval,
+		},
+		{
+			description: "fail, full date after an end month",
+			entry: Entry{
+				Begin: Signature{Date: "", Volume: "", Issue: ""},
+				End:   Signature{Date: "2009-06", Volume: "", Issue: ""}},
+			s:   Signature{Date: "2009-07-01", Volume: "", Issue: ""},
+			err: ErrAfterCoverageInterval,
+		},
+		{
+			description: "pass, open entry",
+			entry:       Entry{Open: true},
+			s:           Signature{Date: "1850", Volume: "", Issue: ""},
+			err:         nil,
+		},
+		{
+			description: "fail, open entry with volume bounds",
+			entry: Entry{
+				Begin: Signature{Date: "", Volume: "10", Issue: ""},
+				Open:  true},
+			s:   Signature{Date: "1850", Volume: "9", Issue: ""},
+			err: ErrBeforeCoverageInterval,
+		},
+		{
+			description: "fail, open entry and no date",
+			entry:       Entry{Open: true},
+			s:           Signature{Date: "", Volume: "1", Issue: ""},
+			err:         ErrMissingValues,
+		},
+		{
+			description: "fail, no bounds",
+			entry:       Entry{},
+			s:           Signature{Date: "1850", Volume: "", Issue: ""},
+			err:         ErrMissingValues,
+		},
 	}
 
 	for _, test := range tests {
@@ -200,3 +242,150 @@ func TestEntryCovers(t *testing.T) {
 		}
 	}
 }
+
+func TestCompareDates(t *testing.T) {
+	var cases = []struct {
+		a, b string
+		want int
+	}{
+		{"2009", "2009", 0},
+		{"2009", "2009-06", 0},
+		{"2009-06-15", "2009-06", 0},
+		{"2009-05", "2009-06", -1},
+		{"2009-06", "2009", 0},
+		{"2010", "2009-12-31", 1},
+		{"2008-12-31", "2009", -1},
+	}
+	for _, c := range cases {
+		if got := CompareDates(c.a, c.b); got != c.want {
+			t.Errorf("CompareDates(%q, %q) got %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestValidISSN(t *testing.T) {
+	var cases = []struct {
+		s     string
+		valid bool
+	}{
+		{"0006-2499", true},
+		{"00062499", true},
+		{"0006-2490", false},
+		{"2434-561X", true},
+		{"2434-561x", true},
+		{"2434-5610", false},
+		{"1234", false},
+		{"", false},
+		{"abcd-efgh", false},
+	}
+	for _, c := range cases {
+		if got := ValidISSN(c.s); got != c.valid {
+			t.Errorf("ValidISSN(%q) got %v, want %v", c.s, got, c.valid)
+		}
+	}
+}
+
+func TestNormalizeISSN(t *testing.T) {
+	var cases = []struct {
+		s    string
+		want string
+		ok   bool
+	}{
+		{"0006-2499", "0006-2499", true},
+		{" 2434561x ", "2434-561X", true},
+		{"0006-2499 (Print)", "0006-2499", true},
+		{"ISSN 0006-2499", "0006-2499", true},
+		{"9783161484100", "", false},
+		{"1234", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		got, ok := NormalizeISSN(c.s)
+		if got != c.want || ok != c.ok {
+			t.Errorf("NormalizeISSN(%q) got %q, %v, want %q, %v", c.s, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestErrorPolicyHandle(t *testing.T) {
+	rerr := &RecordError{Line: 1, Err: ErrMissingValues}
+	var cases = []struct {
+		about     string
+		policy    ErrorPolicy
+		calls     int
+		collected int
+		stop      bool
+	}{
+		{"fail fast", ErrorPolicy{}, 1, 0, true},
+		{"skip silently", ErrorPolicy{Action: SkipSilently}, 3, 0, false},
+		{"collect", ErrorPolicy{Action: SkipAndCollect}, 3, 3, false},
+		{"collect with limit", ErrorPolicy{Action: SkipAndCollect, MaxErrors: 2}, 2, 3, true},
+		{"hook", ErrorPolicy{Hook: func(*RecordError) ErrorAction { return SkipAndCollect }}, 3, 3, false},
+	}
+	for _, c := range cases {
+		var perr ParseError
+		var calls int
+		var err error
+		for calls < 3 && err == nil {
+			calls++
+			err = c.policy.Handle(&perr, rerr)
+		}
+		if calls != c.calls || len(perr.Errors) != c.collected || (err != nil) != c.stop {
+			t.Errorf("%s: got %d calls, %d collected, err %v", c.about, calls, len(perr.Errors), err)
+		}
+	}
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	var cases = []struct {
+		s    string
+		want string
+		ok   bool
+	}{
+		{"3-16-148410-X", "9783161484100", true},
+		{"316148410x", "9783161484100", true},
+		{"978-3-16-148410-0", "9783161484100", true},
+		{"9783161484100", "9783161484100", true},
+		{"978-3-16-148410-1", "", false},
+		{"3-16-148410-0", "", false},
+		{"0006-2499", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		got, ok := NormalizeISBN(c.s)
+		if got != c.want || ok != c.ok {
+			t.Errorf("NormalizeISBN(%q) got %q, %v, want %q, %v", c.s, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestBookCovers(t *testing.T) {
+	book := Book{Edition: "2nd ed.", Date: "2015-03-01"}
+	var cases = []struct {
+		s   Signature
+		err error
+	}{
+		{Signature{}, nil},
+		{Signature{Edition: "2"}, nil},
+		{Signature{Edition: "2", Date: "2015"}, nil},
+		{Signature{Edition: "3"}, ErrEditionMismatch},
+		{Signature{Date: "2016"}, ErrPublicationDateMismatch},
+	}
+	for _, c := range cases {
+		if err := book.Covers(c.s); err != c.err {
+			t.Errorf("Covers(%+v) got %v, want %v", c.s, err, c.err)
+		}
+	}
+	if err := (Book{}).Covers(Signature{Edition: "3", Date: "2016"}); err != nil {
+		t.Errorf("Covers without edition and date got %v, want nil", err)
+	}
+}
+
+func TestEntriesISBN(t *testing.T) {
+	entries := Entries{"9783161484100": []License{Book{Edition: "1"}}}
+	for _, id := range []string{"9783161484100", "978-3-16-148410-0", "3-16-148410-X"} {
+		if got := len(entries.Licenses(id)); got != 1 {
+			t.Errorf("Licenses(%q) got %d licenses, want 1", id, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package holdings
+
+import (
+	"regexp"
+	"strings"
+)
+
+// issnPattern finds an ISSN with or without hyphen, e.g. in "0006-2499
+// (Print)", but not within a longer number.
+var issnPattern = regexp.MustCompile(`(?:^|\D)(\d{4})-?(\d{3}[\dxX])(?:\D|$)`)
+
+// NormalizeISSN finds an ISSN in s and returns it in the form 1234-567X, as
+// holdings keep ISSNs. The check digit is not verified, see ValidISSN.
+func NormalizeISSN(s string) (string, bool) {
+	ms := issnPattern.FindStringSubmatch(s)
+	if ms == nil {
+		return "", false
+	}
+	return ms[1] + "-" + strings.ToUpper(ms[2]), true
+}
+
+// ValidISSN returns true, if s is a well-formed ISSN with a correct check
+// digit. The hyphen is optional.
+func ValidISSN(s string) bool {
+	s = strings.ToUpper(strings.Replace(strings.TrimSpace(s), "-", "", 1))
+	if len(s) != 8 {
+		return false
+	}
+	var sum int
+	for i, c := range s[:7] {
+		if c < '0' || c > '9' {
+			return false
+		}
+		sum += int(c-'0') * (8 - i)
+	}
+	var check byte
+	switch r := (11 - sum%11) % 11; r {
+	case 10:
+		check = 'X'
+	default:
+		check = byte('0' + r)
+	}
+	return s[7] == check
+}
+
+// ZDBKey returns the key, under which a journal is looked up by its number
+// in the German union catalog of serials (ZDB). ISSNs are used as is.
+func ZDBKey(id string) string { return "zdb:" + strings.TrimSpace(id) }
+
+// NormalizeISBN returns an ISBN-10 or ISBN-13 with a correct check digit as
+// ISBN-13 without hyphens. Holdings keep ISBNs in this form, so both forms
+// find the same licenses.
+func NormalizeISBN(s string) (string, bool) {
+	s = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s)))
+	switch len(s) {
+	case 10:
+		var sum int
+		for i, c := range s {
+			switch {
+			case c >= '0' && c <= '9':
+				sum += int(c-'0') * (10 - i)
+			case c == 'X' && i == 9:
+				sum += 10
+			default:
+				return "", false
+			}
+		}
+		if sum%11 != 0 {
+			return "", false
+		}
+		isbn := "978" + s[:9]
+		return isbn + string(isbn13Check(isbn)), true
+	case 13:
+		for _, c := range s {
+			if c < '0' || c > '9' {
+				return "", false
+			}
+		}
+		if !strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979") {
+			return "", false
+		}
+		if isbn13Check(s[:12]) != s[12] {
+			return "", false
+		}
+		return s, true
+	}
+	return "", false
+}
+
+// isbn13Check returns the check digit for the first twelve digits of an
+// ISBN-13.
+func isbn13Check(s string) byte {
+	var sum int
+	for i, c := range s[:12] {
+		w := 1
+		if i%2 == 1 {
+			w = 3
+		}
+		sum += int(c-'0') * w
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,75 @@
+// Package snippet keeps the raw bytes of the record currently read, so
+// errors can show the offending input.
+package snippet
+
+import (
+	"bufio"
+	"io"
+)
+
+// MaxLength is the maximum length of a snippet in bytes.
+const MaxLength = 256
+
+// Reader records bytes read since the last call to Mark. It implements
+// io.ByteReader, so encoding/xml reads through it without extra buffering
+// and decoder offsets correspond to bytes read.
+type Reader struct {
+	r    *bufio.Reader
+	buf  []byte
+	n    int64
+	last byte
+}
+
+// NewReader wraps a reader.
+func NewReader(r io.Reader) *Reader {
+	if br, ok := r.(*bufio.Reader); ok {
+		return &Reader{r: br}
+	}
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read reads into p and records the bytes read.
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	for _, b := range p[:n] {
+		r.record(b)
+	}
+	return n, err
+}
+
+// ReadByte reads and records a single byte.
+func (r *Reader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.record(b)
+	}
+	return b, err
+}
+
+// record keeps a bounded number of bytes.
+func (r *Reader) record(b byte) {
+	r.n++
+	r.last = b
+	if len(r.buf) <= MaxLength {
+		r.buf = append(r.buf, b)
+	}
+}
+
+// Mark discards the recorded bytes before offset, which is usually the
+// input offset of a decoder. A decoder may have read a single byte ahead,
+// which is kept.
+func (r *Reader) Mark(offset int64) {
+	r.buf = r.buf[:0]
+	if r.n-offset == 1 {
+		r.buf = append(r.buf, r.last)
+	}
+}
+
+// String returns the bytes recorded since the last mark, truncated to
+// MaxLength.
+func (r *Reader) String() string {
+	if len(r.buf) > MaxLength {
+		return string(r.buf[:MaxLength]) + "..."
+	}
+	return string(r.buf)
+}
@@ -19,6 +19,15 @@ var (
 	ErrMissingIdentifiers = errors.New("missing identifiers")
 )
 
+// Positions of KBART Phase II columns, used if there is no header.
+const (
+	publicationTypeColumn     = 16
+	monographPrintDateColumn  = 17
+	monographOnlineDateColumn = 18
+	monographEditionColumn    = 20
+	accessTypeColumn          = 24
+)
+
 // delayPattern fixes allowed embargo strings.
 var delayPattern = regexp.MustCompile(`([P|R])([0-9]+)([Y|M|D])`)
 
@@ -50,6 +59,45 @@ type columns struct {
 	Publisher                string
 	Anchor                   string
 	ZDBID                    string
+	AccessType               string
+	PublicationType          string
+	MonographPrintDate       string
+	MonographOnlineDate      string
+	MonographEdition         string
+}
+
+// Monograph returns true, if the row describes a book, either by
+// publication_type or by ISBNs as identifiers.
+func (c columns) Monograph() bool {
+	if strings.EqualFold(c.PublicationType, "monograph") {
+		return true
+	}
+	if strings.EqualFold(c.PublicationType, "serial") {
+		return false
+	}
+	for _, id := range []string{c.PrintIdentifier, c.OnlineIdentifier} {
+		if _, ok := holdings.NormalizeISBN(id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Book returns the license of a monograph row. The online publication date
+// is preferred.
+func (c columns) Book() holdings.Book {
+	date := c.MonographOnlineDate
+	if date == "" {
+		date = c.MonographPrintDate
+	}
+	return holdings.Book{
+		Edition: c.MonographEdition,
+		Date:    date,
+		Title:   strings.TrimSpace(c.PublicationTitle),
+		Comment: strings.TrimSpace(c.CoverageNotes),
+		URL:     strings.TrimSpace(c.TitleURL),
+		Access:  accessType(c.AccessType),
+	}
 }
 
 // Convert string like P12M, P1M, R10Y into a time.Duration.
@@ -73,11 +121,11 @@ func (e embargo) AsDuration() (time.Duration, error) {
 
 	switch parts[3] {
 	case "D":
-		return time.Duration(-i) * 24 * time.Hour, nil
+		return time.Duration(-i) * holdings.Day, nil
 	case "M":
-		return time.Duration(-i) * 24 * time.Hour * 30, nil
+		return time.Duration(-i) * holdings.Month, nil
 	case "Y":
-		return time.Duration(-i) * 24 * time.Hour * 30 * 365, nil
+		return time.Duration(-i) * holdings.Year, nil
 	default:
 		return d, ErrInvalidEmbargo
 	}
@@ -94,22 +142,66 @@ func (e embargo) DisallowEarlier() bool {
 type Reader struct {
 	r          *bufio.Reader
 	currentRow int
-
-	SkipFirstRow           bool
+	line       int
+	raw        string
+	header     map[string]int
+
+	SkipFirstRow bool
+	Policy       holdings.ErrorPolicy
+	// Online, if not nil, records the online identifiers read by ReadAll,
+	// since entries do not tell print and online identifiers apart.
+	Online map[string]bool
+
+	// Deprecated: Set Policy instead. The Skip fields only take effect
+	// with the policy set by NewReader.
 	SkipMissingIdentifiers bool
-	SkipIncompleteLines    bool
-	SkipInvalidEmbargo     bool
+	// Deprecated: Set Policy instead.
+	SkipIncompleteLines bool
+	// Deprecated: Set Policy instead.
+	SkipInvalidEmbargo bool
 }
 
-// NewReader creates a new KBART reader.
+// NewReader creates a new KBART reader. By default, lines without
+// identifiers and lines with an incomplete embargo are skipped, other errors
+// stop reading.
 func NewReader(r io.Reader) *Reader {
-	return &Reader{SkipFirstRow: true, SkipMissingIdentifiers: true, r: bufio.NewReader(r)}
+	kr := &Reader{
+		SkipFirstRow:           true,
+		SkipMissingIdentifiers: true,
+		r:                      bufio.NewReader(r),
+	}
+	kr.Policy = holdings.ErrorPolicy{Hook: kr.skip}
+	return kr
+}
+
+// skip is the default error policy hook, that maps the deprecated Skip
+// fields onto error actions.
+func (r *Reader) skip(err *holdings.RecordError) holdings.ErrorAction {
+	var skip bool
+	switch err.Err {
+	case ErrMissingIdentifiers:
+		skip = r.SkipMissingIdentifiers
+	case ErrIncompleteLine:
+		skip = r.SkipIncompleteLines
+	case ErrInvalidEmbargo:
+		skip = r.SkipInvalidEmbargo
+	case ErrIncompleteEmbargo:
+		// never stopped reading
+		skip = true
+	}
+	if skip {
+		return holdings.SkipSilently
+	}
+	return holdings.FailFast
 }
 
 // ReadAll loads entries from a reader.
 func (r *Reader) ReadAll() (holdings.Entries, error) {
 	entries := make(holdings.Entries)
 
+	// collect errors, if the policy says so
+	perr := holdings.ParseError{}
+
 	for {
 		cols, entry, err := r.Read()
 
@@ -118,48 +210,89 @@ func (r *Reader) ReadAll() (holdings.Entries, error) {
 		}
 
 		switch err {
-		case ErrMissingIdentifiers:
-			if !r.SkipMissingIdentifiers {
-				return entries, err
-			}
-		case ErrIncompleteLine:
-			if !r.SkipIncompleteLines {
-				return entries, err
-			}
-		case ErrInvalidEmbargo:
-			if !r.SkipInvalidEmbargo {
+		case nil:
+		case ErrIncompleteLine, ErrIncompleteEmbargo, ErrInvalidEmbargo:
+			if err := r.Policy.Handle(&perr, r.recordError(err)); err != nil {
 				return entries, err
 			}
+			continue
+		default:
+			return entries, err
 		}
 
 		pi := strings.TrimSpace(cols.PrintIdentifier)
 		oi := strings.TrimSpace(cols.OnlineIdentifier)
 
 		if pi == "" && oi == "" {
-			if !r.SkipMissingIdentifiers {
-				return entries, ErrMissingIdentifiers
+			if err := r.Policy.Handle(&perr, r.recordError(ErrMissingIdentifiers)); err != nil {
+				return entries, err
+			}
+			continue
+		}
+		// books are kept under their ISBN-13
+		var license holdings.License = entry
+		if cols.Monograph() {
+			license = cols.Book()
+			for _, id := range []*string{&pi, &oi} {
+				if isbn, ok := holdings.NormalizeISBN(*id); ok {
+					*id = isbn
+				}
 			}
 		}
 		if pi != "" {
-			entries[pi] = append(entries[pi], holdings.License(entry))
+			entries[pi] = append(entries[pi], license)
 		}
-		if oi != "" {
-			entries[oi] = append(entries[oi], holdings.License(entry))
+		if oi != "" && oi != pi {
+			entries[oi] = append(entries[oi], license)
+			if r.Online != nil {
+				r.Online[oi] = true
+			}
 		}
 	}
 
+	if len(perr.Errors) > 0 {
+		return entries, perr
+	}
 	return entries, nil
 }
 
+// Line returns the number of the line read last, starting at 1.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// recordError attaches line number, identifier and the raw line to an error.
+func (r *Reader) recordError(err error) *holdings.RecordError {
+	var id string
+	if record := strings.Split(r.raw, "\t"); len(record) > 2 {
+		id = strings.TrimSpace(record[1])
+		if id == "" {
+			id = strings.TrimSpace(record[2])
+		}
+	}
+	return &holdings.RecordError{
+		Line:    r.line,
+		Record:  id,
+		Snippet: strings.TrimRight(r.raw, "\r\n"),
+		Err:     err,
+	}
+}
+
 // Read reads a single line.
 func (r *Reader) Read() (columns, holdings.Entry, error) {
 	var entry holdings.Entry
 	var cols columns
 
 	if r.SkipFirstRow && r.currentRow == 0 {
-		if _, err := r.r.ReadString('\n'); err != nil {
+		header, err := r.r.ReadString('\n')
+		if err != nil {
 			return cols, entry, err
 		}
+		r.line++
+		r.header = make(map[string]int)
+		for i, name := range strings.Split(strings.TrimRight(header, "\r\n"), "\t") {
+			r.header[strings.TrimSpace(name)] = i
+		}
 	}
 	r.currentRow++
 
@@ -168,6 +301,8 @@ func (r *Reader) Read() (columns, holdings.Entry, error) {
 
 	for {
 		line, err = r.r.ReadString('\n')
+		r.line++
+		r.raw = line
 		if strings.TrimSpace(line) != "" {
 			break
 		}
@@ -189,6 +324,7 @@ func (r *Reader) Read() (columns, holdings.Entry, error) {
 	}
 
 	cols = columns{
+		PublicationTitle: record[0],
 		PrintIdentifier:  record[1],
 		OnlineIdentifier: record[2],
 		FirstIssueDate:   record[3],
@@ -197,7 +333,15 @@ func (r *Reader) Read() (columns, holdings.Entry, error) {
 		LastIssueDate:    record[6],
 		LastVolume:       record[7],
 		LastIssue:        record[8],
+		TitleURL:         record[9],
 		Embargo:          embargo(record[12]),
+		CoverageNotes:    record[14],
+		AccessType:       r.field(record, "access_type", accessTypeColumn),
+
+		PublicationType:     r.field(record, "publication_type", publicationTypeColumn),
+		MonographPrintDate:  r.field(record, "date_monograph_published_print", monographPrintDateColumn),
+		MonographOnlineDate: r.field(record, "date_monograph_published_online", monographOnlineDateColumn),
+		MonographEdition:    r.field(record, "monograph_edition", monographEditionColumn),
 	}
 
 	emb, err := cols.Embargo.AsDuration()
@@ -218,7 +362,34 @@ func (r *Reader) Read() (columns, holdings.Entry, error) {
 		},
 		Embargo:                emb,
 		EmbargoDisallowEarlier: cols.Embargo.DisallowEarlier(),
+		Title:                  strings.TrimSpace(cols.PublicationTitle),
+		Comment:                strings.TrimSpace(cols.CoverageNotes),
+		URL:                    strings.TrimSpace(cols.TitleURL),
+		Access:                 accessType(cols.AccessType),
 	}
 
 	return cols, entry, nil
 }
+
+// field returns a column by header name or, without header, by position.
+func (r *Reader) field(record []string, name string, position int) string {
+	i, ok := r.header[name]
+	if r.header == nil {
+		i, ok = position, true
+	}
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// accessType maps the KBART access_type, F for free and P for paid.
+func accessType(s string) holdings.AccessType {
+	switch strings.ToUpper(s) {
+	case "F":
+		return holdings.FreeAccess
+	case "P":
+		return holdings.LicensedAccess
+	}
+	return ""
+}
@@ -1,6 +1,7 @@
 package kbart
 
 import (
+	"errors"
 	"io"
 	"reflect"
 	"strings"
@@ -86,6 +87,8 @@ Bill of Rights Journal (via Hein Online)	0006-2499		1968	1		1996	29		http://hein
 						},
 						Embargo:                time.Duration(0),
 						EmbargoDisallowEarlier: false,
+						Title:                  "Bill of Rights Journal (via Hein Online)",
+						URL:                    "http://heinonline.org/HOL/Index?index=journals/blorij&collection=journals",
 					}}},
 			err: nil},
 		// Beware: KBART files must end with newline, otherwise the last row is ignored.
@@ -108,6 +111,8 @@ Bill of Rights Journal (via Hein Online)	0006-2499		1968	1		1996	29		http://hein
 						},
 						Embargo:                time.Duration(0),
 						EmbargoDisallowEarlier: false,
+						Title:                  "Bill of Rights Journal (via Hein Online)",
+						URL:                    "http://heinonline.org/HOL/Index?index=journals/blorij&collection=journals",
 					}}},
 			err: nil},
 	}
@@ -118,14 +123,125 @@ Bill of Rights Journal (via Hein Online)	0006-2499		1968	1		1996	29		http://hein
 		reader.SkipFirstRow = true
 
 		entries, err := reader.ReadAll()
-		if err != c.err {
+		if !errors.Is(err, c.err) {
 			t.Errorf("ReadAll got %+v, want %+v", err, c.err)
 		}
 		if !reflect.DeepEqual(entries, c.entries) {
 			t.Errorf("ReadAll got %+v, want %+v", entries, c.entries)
 			for _, s := range pretty.Diff(c.entries, entries) {
-				t.Errorf(s)
+				t.Error(s)
 			}
 		}
 	}
 }
+
+func TestReadAllRecordError(t *testing.T) {
+	r := NewReader(strings.NewReader("header\n\nxxx\tyyy\tzzz\n"))
+	_, err := r.ReadAll()
+	var rerr *holdings.RecordError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("ReadAll got %v, want *holdings.RecordError", err)
+	}
+	if rerr.Line != 3 || rerr.Record != "yyy" || rerr.Snippet != "xxx\tyyy\tzzz" {
+		t.Errorf("got line %d, record %q, snippet %q", rerr.Line, rerr.Record, rerr.Snippet)
+	}
+	if !errors.Is(err, ErrIncompleteLine) {
+		t.Errorf("got %v, want %v", err, ErrIncompleteLine)
+	}
+}
+
+func TestDeprecatedSkipFields(t *testing.T) {
+	r := NewReader(strings.NewReader("header\nxxx\tyyy\tzzz\n"))
+	r.SkipIncompleteLines = true
+	if _, err := r.ReadAll(); err != nil {
+		t.Errorf("SkipIncompleteLines: got %v, want nil", err)
+	}
+	record := make([]string, len(phaseII))
+	record[0], record[1], record[12] = "Journal", "0006-2499", "1Y"
+	r = NewReader(strings.NewReader("header\n" + strings.Join(record, "\t") + "\n"))
+	if _, err := r.ReadAll(); err != nil {
+		t.Errorf("incomplete embargo: got %v, want nil", err)
+	}
+	record[1], record[12] = "", ""
+	r = NewReader(strings.NewReader("header\n" + strings.Join(record, "\t") + "\n"))
+	r.SkipMissingIdentifiers = false
+	if _, err := r.ReadAll(); !errors.Is(err, ErrMissingIdentifiers) {
+		t.Errorf("SkipMissingIdentifiers: got %v, want %v", err, ErrMissingIdentifiers)
+	}
+}
+
+func TestAccessType(t *testing.T) {
+	header := strings.Join(phaseII, "\t") + "\n"
+	row := func(issn, access string) string {
+		record := make([]string, len(phaseII))
+		record[0], record[1], record[3], record[24] = "Journal", issn, "2001", access
+		return strings.Join(record, "\t") + "\n"
+	}
+	doc := header + row("0006-2499", "F") + row("1613-4141", "P") + row("2345-6789", "")
+	for _, skip := range []bool{true, false} {
+		in := doc
+		if !skip {
+			in = strings.TrimPrefix(doc, header)
+		}
+		r := NewReader(strings.NewReader(in))
+		r.SkipFirstRow = skip
+		entries, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for id, want := range map[string]holdings.AccessType{
+			"0006-2499": holdings.FreeAccess,
+			"1613-4141": holdings.LicensedAccess,
+			"2345-6789": "",
+		} {
+			if got := entries[id][0].(holdings.Entry).Access; got != want {
+				t.Errorf("%s: got access %q, want %q", id, got, want)
+			}
+		}
+	}
+}
+
+func TestReadAllOnline(t *testing.T) {
+	record := make([]string, len(phaseII))
+	record[0], record[1], record[2], record[3] = "Journal", "0006-2499", "1521-4036", "2001"
+	r := NewReader(strings.NewReader("header\n" + strings.Join(record, "\t") + "\n"))
+	r.Online = make(map[string]bool)
+	if _, err := r.ReadAll(); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"1521-4036": true}; !reflect.DeepEqual(r.Online, want) {
+		t.Errorf("Online: got %v, want %v", r.Online, want)
+	}
+}
+
+func TestMonograph(t *testing.T) {
+	header := strings.Join(phaseII, "\t") + "\n"
+	row := func(pi, oi, typ, edition string) string {
+		record := make([]string, len(phaseII))
+		record[0], record[1], record[2] = "Book", pi, oi
+		record[9], record[16], record[18], record[20] = "http://example.com/b", typ, "2015", edition
+		return strings.Join(record, "\t") + "\n"
+	}
+	doc := header +
+		row("3-16-148410-X", "978-1-4028-9462-6", "monograph", "2") +
+		row("0-306-40615-2", "", "", "") +
+		row("0006-2499", "", "serial", "")
+	r := NewReader(strings.NewReader(doc))
+	r.SkipFirstRow = true
+	entries, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := holdings.Book{Title: "Book", Edition: "2", Date: "2015", URL: "http://example.com/b"}
+	for _, id := range []string{"9783161484100", "9781402894626"} {
+		if len(entries[id]) != 1 || entries[id][0] != holdings.License(want) {
+			t.Errorf("%s: got %+v, want %+v", id, entries[id], want)
+		}
+	}
+	if _, ok := entries["9780306406157"][0].(holdings.Book); !ok {
+		t.Errorf("ISBN without publication_type: got %+v, want book", entries["9780306406157"])
+	}
+	if _, ok := entries["0006-2499"][0].(holdings.Entry); !ok {
+		t.Errorf("serial: got %+v, want entry", entries["0006-2499"])
+	}
+}
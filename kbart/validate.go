@@ -0,0 +1,268 @@
+package kbart
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/miku/holdings"
+)
+
+// Rule names, see Rules for a description.
+const (
+	RuleMandatoryColumns = "mandatory-columns"
+	RulePhaseIIColumns   = "phase-ii-columns"
+	RuleColumnCount      = "column-count"
+	RuleMandatoryValues  = "mandatory-values"
+	RuleISSN             = "issn"
+	RuleISBN             = "isbn"
+	RuleDateFormat       = "date-format"
+	RuleDateOrder        = "date-order"
+	RuleEmbargo          = "embargo"
+	RuleURL              = "url"
+	RuleCoverageDepth    = "coverage-depth"
+	RuleDuplicateTitle   = "duplicate-title"
+)
+
+// Rules is the catalog of checks performed by Validate.
+var Rules = holdings.Catalog{
+	{Name: RuleMandatoryColumns, Severity: holdings.Error, Description: "header must contain the KBART columns publication_title to coverage_depth in order"},
+	{Name: RulePhaseIIColumns, Severity: holdings.Warning, Description: "header should contain all KBART Phase II columns"},
+	{Name: RuleColumnCount, Severity: holdings.Error, Description: "row must have as many columns as the header"},
+	{Name: RuleMandatoryValues, Severity: holdings.Error, Description: "publication_title, title_url and at least one identifier must be set"},
+	{Name: RuleISSN, Severity: holdings.Error, Description: "serial identifiers must be ISSN with a valid check digit"},
+	{Name: RuleISBN, Severity: holdings.Error, Description: "monograph identifiers must be ISBN-10 or ISBN-13 with a valid check digit"},
+	{Name: RuleDateFormat, Severity: holdings.Error, Description: "dates must be formatted as YYYY, YYYY-MM or YYYY-MM-DD"},
+	{Name: RuleDateOrder, Severity: holdings.Error, Description: "first issue must not be later than last issue"},
+	{Name: RuleEmbargo, Severity: holdings.Error, Description: "embargo_info must look like R1Y, P6M or R10Y;P30D"},
+	{Name: RuleURL, Severity: holdings.Error, Description: "title_url must be an absolute http or https URL"},
+	{Name: RuleCoverageDepth, Severity: holdings.Warning, Description: "coverage_depth should be one of fulltext, abstracts, selected articles"},
+	{Name: RuleDuplicateTitle, Severity: holdings.Warning, Description: "the same title and coverage should appear only once"},
+}
+
+// phaseII lists the KBART Phase II columns in their mandated order.
+var phaseII = []string{
+	"publication_title",
+	"print_identifier",
+	"online_identifier",
+	"date_first_issue_online",
+	"num_first_vol_online",
+	"num_first_issue_online",
+	"date_last_issue_online",
+	"num_last_vol_online",
+	"num_last_issue_online",
+	"title_url",
+	"first_author",
+	"title_id",
+	"embargo_info",
+	"coverage_depth",
+	"notes",
+	"publisher_name",
+	"publication_type",
+	"date_monograph_published_print",
+	"date_monograph_published_online",
+	"monograph_volume",
+	"monograph_edition",
+	"first_editor",
+	"parent_publication_title_id",
+	"preceding_publication_title_id",
+	"access_type",
+}
+
+// mandatory is the number of leading phase II columns, that must appear in
+// exactly this order in every KBART file.
+const mandatory = 14
+
+var (
+	datePattern    = regexp.MustCompile(`^[0-9]{4}(-[0-9]{2}(-[0-9]{2})?)?$`)
+	embargoPattern = regexp.MustCompile(`^[PR][0-9]+[DMY](;[PR][0-9]+[DMY])?$`)
+	dateLayouts    = []string{"2006", "2006-01", "2006-01-02"}
+)
+
+// validator keeps the state needed across rows.
+type validator struct {
+	holdings.Collector
+	index map[string]int
+	seen  map[string]int
+	width int
+}
+
+// add records a problem for a given field of the current line, located at
+// the column of the field, if there is one.
+func (v *validator) add(rule, field, value, msg string) {
+	v.At.Column = 0
+	if i, ok := v.index[field]; ok {
+		v.At.Column = i + 1
+	}
+	v.Add(rule, field, value, msg)
+}
+
+// header checks column names and remembers their position. A byte order
+// mark before the first column name is ignored.
+func (v *validator) header(record []string) {
+	v.width = len(record)
+	for i, name := range record {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		v.index[strings.TrimSpace(name)] = i
+	}
+	for i, name := range phaseII {
+		j, ok := v.index[name]
+		switch {
+		case i < mandatory && !ok:
+			v.add(RuleMandatoryColumns, "", name, "missing column")
+		case i < mandatory && j != i:
+			v.add(RuleMandatoryColumns, name, "", fmt.Sprintf("column expected at position %d", i+1))
+		case !ok:
+			v.add(RulePhaseIIColumns, "", name, "missing column")
+		}
+	}
+}
+
+// row runs all row based rules.
+func (v *validator) row(record []string) {
+	get := func(field string) string {
+		if i, ok := v.index[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	if len(record) < v.width {
+		v.add(RuleColumnCount, "", "", fmt.Sprintf("got %d column(s), want %d", len(record), v.width))
+	}
+
+	pi, oi := get("print_identifier"), get("online_identifier")
+
+	if get("publication_title") == "" {
+		v.add(RuleMandatoryValues, "publication_title", "", "missing value")
+	}
+	if get("title_url") == "" {
+		v.add(RuleMandatoryValues, "title_url", "", "missing value")
+	}
+	if pi == "" && oi == "" {
+		v.add(RuleMandatoryValues, "", "", "missing print_identifier and online_identifier")
+	}
+
+	// same detection as the reader
+	cols := columns{PrintIdentifier: pi, OnlineIdentifier: oi, PublicationType: get("publication_type")}
+	for _, field := range []string{"print_identifier", "online_identifier"} {
+		s := get(field)
+		switch {
+		case s == "":
+		case cols.Monograph():
+			if _, ok := holdings.NormalizeISBN(s); !ok {
+				v.add(RuleISBN, field, s, "invalid ISBN")
+			}
+		case !holdings.ValidISSN(s):
+			v.add(RuleISSN, field, s, "invalid ISSN")
+		}
+	}
+
+	first, last := get("date_first_issue_online"), get("date_last_issue_online")
+	valid := true
+	for _, field := range []string{"date_first_issue_online", "date_last_issue_online"} {
+		if s := get(field); s != "" && !validDate(s) {
+			v.add(RuleDateFormat, field, s, "invalid date")
+			valid = false
+		}
+	}
+	if valid && first != "" && last != "" && holdings.CompareDates(first, last) > 0 {
+		v.add(RuleDateOrder, "date_last_issue_online", last, "last issue before first issue "+first)
+	}
+	if fv, lv := get("num_first_vol_online"), get("num_last_vol_online"); fv != "" && lv != "" {
+		s, t := holdings.Signature{Volume: fv}, holdings.Signature{Volume: lv}
+		if s.VolumeInt() > t.VolumeInt() {
+			v.add(RuleDateOrder, "num_last_vol_online", lv, "last volume before first volume "+fv)
+		}
+	}
+
+	if s := get("embargo_info"); s != "" && !embargoPattern.MatchString(s) {
+		v.add(RuleEmbargo, "embargo_info", s, "invalid embargo")
+	}
+
+	if s := get("title_url"); s != "" {
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.add(RuleURL, "title_url", s, "invalid URL")
+		}
+	}
+
+	if s := get("coverage_depth"); s != "" {
+		switch strings.ToLower(s) {
+		case "fulltext", "abstracts", "selected articles":
+		default:
+			v.add(RuleCoverageDepth, "coverage_depth", s, "unknown coverage depth")
+		}
+	}
+
+	key := strings.Join([]string{pi, oi, get("title_id"), first, get("num_first_vol_online"),
+		get("num_first_issue_online"), last, get("num_last_vol_online"), get("num_last_issue_online")}, "\t")
+	if line, ok := v.seen[key]; ok {
+		v.add(RuleDuplicateTitle, "", "", fmt.Sprintf("duplicate of line %d", line))
+	} else {
+		v.seen[key] = v.At.Line
+	}
+}
+
+// validDate checks the format and the ranges of month and day.
+func validDate(s string) bool {
+	if !datePattern.MatchString(s) {
+		return false
+	}
+	for _, layout := range dateLayouts {
+		if len(layout) == len(s) {
+			_, err := time.Parse(layout, s)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// Validate reads the remaining input and checks every line against the
+// rules in the catalog. If SkipFirstRow is set, the first row is taken as
+// header, otherwise Phase II column order is assumed. The returned error is
+// only non-nil for I/O errors, problems with the data end up in the report.
+func (r *Reader) Validate() (holdings.Report, error) {
+	v := &validator{
+		Collector: holdings.Collector{Rules: Rules},
+		index:     make(map[string]int),
+		seen:      make(map[string]int),
+	}
+
+	if !r.SkipFirstRow {
+		for i, name := range phaseII {
+			v.index[name] = i
+		}
+		v.width = mandatory
+	}
+
+	for {
+		line, err := r.r.ReadString('\n')
+		if line == "" && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return v.Report, err
+		}
+		v.At.Line++
+		r.currentRow++
+
+		record := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+		switch {
+		case v.At.Line == 1 && r.SkipFirstRow:
+			v.header(record)
+		case strings.TrimSpace(line) == "":
+		default:
+			v.Report.Records++
+			v.row(record)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	return v.Report, nil
+}
@@ -0,0 +1,94 @@
+package kbart
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const validateHeader = "publication_title\tprint_identifier\tonline_identifier\tdate_first_issue_online\tnum_first_vol_online\tnum_first_issue_online\tdate_last_issue_online\tnum_last_vol_online\tnum_last_issue_online\ttitle_url\tfirst_author\ttitle_id\tembargo_info\tcoverage_depth\tnotes\tpublisher_name\tpublication_type\tdate_monograph_published_print\tdate_monograph_published_online\tmonograph_volume\tmonograph_edition\tfirst_editor\tparent_publication_title_id\tpreceding_publication_title_id\taccess_type\n"
+
+// row builds a tab separated line from the first columns, padding the rest.
+func row(cols ...string) string {
+	for len(cols) < len(phaseII) {
+		cols = append(cols, "")
+	}
+	return strings.Join(cols, "\t") + "\n"
+}
+
+func TestValidate(t *testing.T) {
+	var cases = []struct {
+		about   string
+		input   string
+		records int
+		// problems as rule@line
+		problems []string
+	}{
+		{
+			about:   "valid file",
+			input:   validateHeader + row("Journal", "0006-2499", "", "1968", "1", "", "1996", "29", "", "http://example.com/j", "", "1", "P1Y", "fulltext"),
+			records: 1,
+		},
+		{
+			about:   "dates of different precision",
+			input:   validateHeader + row("Journal", "0006-2499", "", "1996-05", "1", "", "1996", "29", "", "http://example.com/j", "", "1", "P1Y", "fulltext"),
+			records: 1,
+		},
+		{
+			about:   "byte order mark before the header",
+			input:   "\ufeff" + validateHeader + row("Journal", "0006-2499", "", "1968", "1", "", "1996", "29", "", "http://example.com/j", "", "1", "P1Y", "fulltext"),
+			records: 1,
+		},
+		{
+			about:    "missing columns in header",
+			input:    strings.Replace(strings.Replace(validateHeader, "\tcoverage_depth", "", 1), "\taccess_type", "", 1),
+			problems: []string{"mandatory-columns@1", "phase-ii-columns@1"},
+		},
+		{
+			about: "bad values",
+			input: validateHeader +
+				row("Journal", "0006-2490", "", "1968-13", "1", "", "1996", "29", "", "ftp://example.com", "", "1", "1Y", "Volltext") +
+				row("", "", "", "2000", "5", "", "1999", "4", "", "http://example.com/j"),
+			records: 2,
+			problems: []string{"issn@2", "date-format@2", "embargo@2", "url@2", "coverage-depth@2",
+				"mandatory-values@3", "mandatory-values@3", "date-order@3", "date-order@3"},
+		},
+		{
+			about: "monographs",
+			input: validateHeader +
+				row("Book", "978-3-16-148410-0", "", "", "", "", "", "", "", "http://example.com/b") +
+				row("Book", "978-3-16-148410-1", "", "", "", "", "", "", "", "http://example.com/b", "", "", "", "", "", "", "monograph") +
+				row("Book", "978-3-16-148410-0", "3-16-148410-0", "", "", "", "", "", "", "http://example.com/c"),
+			records:  3,
+			problems: []string{"isbn@3", "isbn@4"},
+		},
+		{
+			about: "duplicate rows and short lines",
+			input: validateHeader +
+				row("Journal", "0006-2499", "", "1968", "1", "", "1996", "29", "", "http://example.com/j") +
+				row("Journal", "0006-2499", "", "1968", "1", "", "1996", "29", "", "http://example.com/j") +
+				"Journal\t0006-2499\n",
+			records:  3,
+			problems: []string{"duplicate-title@3", "column-count@4", "mandatory-values@4"},
+		},
+	}
+
+	for _, c := range cases {
+		r := NewReader(strings.NewReader(c.input))
+		report, err := r.Validate()
+		if err != nil {
+			t.Errorf("%s: Validate failed with %s", c.about, err)
+			continue
+		}
+		if report.Records != c.records {
+			t.Errorf("%s: got %d records, want %d", c.about, report.Records, c.records)
+		}
+		var got []string
+		for _, p := range report.Problems {
+			got = append(got, fmt.Sprintf("%s@%d", p.Rule, p.Line))
+		}
+		if strings.Join(got, " ") != strings.Join(c.problems, " ") {
+			t.Errorf("%s: got problems %v, want %v", c.about, got, c.problems)
+		}
+	}
+}
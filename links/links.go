@@ -0,0 +1,184 @@
+// Package links builds target URLs for records, that a license grants
+// access to. The title URL of a license, e.g. KBART title_url, is the base;
+// per platform templates add deep links to articles and issues:
+//
+//	[
+//	  {
+//	    "name": "springer",
+//	    "match": "link.springer.com/journal/",
+//	    "article": ["https://link.springer.com/{doi}"],
+//	    "issue": ["{base}/volumes-and-issues/{volume}-{issue}"]
+//	  },
+//	  {"name": "default", "article": ["{base}/doi/{doi}", "{base}/{volume}/{issue}/{spage}"]}
+//	]
+//
+// Templates are tried from article to issue level; the title URL itself is
+// the journal level link. A template is only used, if all of its variables
+// have values. Variables are base, doi, issn, year, volume, issue and spage.
+package links
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/miku/holdings"
+)
+
+var variablePattern = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// Level tells, how specific a link is.
+type Level string
+
+const (
+	ArticleLevel Level = "article"
+	IssueLevel   Level = "issue"
+	JournalLevel Level = "journal"
+)
+
+// Link is a target URL.
+type Link struct {
+	URL   string `json:"url"`
+	Level Level  `json:"level"`
+}
+
+// Article describes the record to link to.
+type Article struct {
+	ISSN      string
+	DOI       string
+	Signature holdings.Signature
+	StartPage string
+}
+
+// values returns the template variables of an article.
+func (a Article) values(base string) map[string]string {
+	var year string
+	if len(a.Signature.Date) >= 4 {
+		year = a.Signature.Date[:4]
+	}
+	return map[string]string{
+		"base":   strings.TrimRight(base, "/"),
+		"doi":    a.DOI,
+		"issn":   a.ISSN,
+		"year":   year,
+		"volume": a.Signature.Volume,
+		"issue":  a.Signature.Issue,
+		"spage":  a.StartPage,
+	}
+}
+
+// Template holds the deep link templates of a platform. Match is a prefix
+// of the title URLs of the platform, without scheme; an empty Match applies
+// to all title URLs.
+type Template struct {
+	Name    string   `json:"name"`
+	Match   string   `json:"match"`
+	Article []string `json:"article"`
+	Issue   []string `json:"issue"`
+}
+
+// Templates are the templates of all platforms.
+type Templates []Template
+
+// ReadTemplates reads templates from a JSON file.
+func ReadTemplates(r io.Reader) (Templates, error) {
+	var t Templates
+	if err := json.NewDecoder(r).Decode(&t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// stripScheme removes http:// or https:// from a URL.
+func stripScheme(s string) string {
+	for _, prefix := range []string{"https://", "http://"} {
+		if strings.HasPrefix(s, prefix) {
+			return s[len(prefix):]
+		}
+	}
+	return s
+}
+
+// lookup returns the template with the longest match for a title URL.
+func (t Templates) lookup(base string) (Template, bool) {
+	var best Template
+	var found bool
+	base = stripScheme(base)
+	for _, tmpl := range t {
+		match := stripScheme(tmpl.Match)
+		if !strings.HasPrefix(base, match) {
+			continue
+		}
+		if !found || len(match) > len(stripScheme(best.Match)) {
+			best, found = tmpl, true
+		}
+	}
+	return best, found
+}
+
+// expand fills in a template and reports, whether all variables had values.
+// Values are escaped for the path or, after a question mark, for the query.
+func expand(tmpl string, values map[string]string) (string, bool) {
+	ok := true
+	query := strings.Index(tmpl, "?")
+	var sb strings.Builder
+	var last int
+	for _, loc := range variablePattern.FindAllStringIndex(tmpl, -1) {
+		sb.WriteString(tmpl[last:loc[0]])
+		last = loc[1]
+		name := tmpl[loc[0]+1 : loc[1]-1]
+		value, found := values[name]
+		switch {
+		case !found || value == "":
+			ok = false
+		case name == "base":
+			sb.WriteString(value)
+		case query >= 0 && loc[0] > query:
+			sb.WriteString(url.QueryEscape(value))
+		default:
+			// keep slashes, e.g. in DOI
+			sb.WriteString(strings.ReplaceAll(url.PathEscape(value), "%2F", "/"))
+		}
+	}
+	sb.WriteString(tmpl[last:])
+	return sb.String(), ok
+}
+
+// Link returns the best link for an article, starting from a title URL.
+// Without a title URL, only templates not using base can produce a link.
+func (t Templates) Link(base string, a Article) (Link, bool) {
+	if tmpl, ok := t.lookup(base); ok {
+		values := a.values(base)
+		for _, level := range []struct {
+			level     Level
+			templates []string
+		}{
+			{ArticleLevel, tmpl.Article},
+			{IssueLevel, tmpl.Issue},
+		} {
+			for _, s := range level.templates {
+				if u, ok := expand(s, values); ok {
+					return Link{URL: u, Level: level.level}, true
+				}
+			}
+		}
+	}
+	if base == "" {
+		return Link{}, false
+	}
+	return Link{URL: base, Level: JournalLevel}, true
+}
+
+// Decision returns the best link for an article, that a decision grants
+// access to, starting from the title URL of the license.
+func (t Templates) Decision(d holdings.Decision, a Article) (Link, bool) {
+	if !d.OK {
+		return Link{}, false
+	}
+	if a.ISSN == "" {
+		a.ISSN = d.Identifier
+	}
+	return t.Link(holdings.URLOf(d.License), a)
+}
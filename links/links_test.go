@@ -0,0 +1,65 @@
+package links
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/miku/holdings"
+)
+
+const config = `[
+  {
+    "name": "springer",
+    "match": "link.springer.com/journal/",
+    "article": ["https://link.springer.com/{doi}"],
+    "issue": ["{base}/volumes-and-issues/{volume}-{issue}"]
+  },
+  {"name": "search", "match": "example.org/", "article": ["{base}/search?q={doi}&v={volume}"]},
+  {"name": "default", "article": ["{base}/doi/{doi}", "{base}/{volume}/{issue}/{spage}"]}
+]`
+
+func TestLink(t *testing.T) {
+	templates, err := ReadTemplates(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := holdings.Signature{Date: "2001", Volume: "10", Issue: "2"}
+	var cases = []struct {
+		base string
+		a    Article
+		want Link
+		ok   bool
+	}{
+		{"https://link.springer.com/journal/10", Article{DOI: "10.1007/s001", Signature: sig},
+			Link{URL: "https://link.springer.com/10.1007/s001", Level: ArticleLevel}, true},
+		{"https://link.springer.com/journal/10/", Article{Signature: sig},
+			Link{URL: "https://link.springer.com/journal/10/volumes-and-issues/10-2", Level: IssueLevel}, true},
+		{"https://link.springer.com/journal/10", Article{},
+			Link{URL: "https://link.springer.com/journal/10", Level: JournalLevel}, true},
+		{"http://example.com/j", Article{Signature: sig, StartPage: "123"},
+			Link{URL: "http://example.com/j/10/2/123", Level: ArticleLevel}, true},
+		{"http://example.com/j", Article{DOI: "10.1000/a b"},
+			Link{URL: "http://example.com/j/doi/10.1000/a%20b", Level: ArticleLevel}, true},
+		{"", Article{DOI: "10.1000/x"}, Link{}, false},
+		{"http://example.org/j", Article{DOI: "10.1000/a&b=c+d", Signature: sig},
+			Link{URL: "http://example.org/j/search?q=10.1000%2Fa%26b%3Dc%2Bd&v=10", Level: ArticleLevel}, true},
+	}
+	for _, c := range cases {
+		got, ok := templates.Link(c.base, c.a)
+		if got != c.want || ok != c.ok {
+			t.Errorf("Link(%q, %+v): got %+v, %v, want %+v, %v", c.base, c.a, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestDecision(t *testing.T) {
+	d := holdings.Check(holdings.Entries{
+		"0006-2499": []holdings.License{holdings.Entry{URL: "http://example.com/j", Begin: holdings.Signature{Date: "1990"}}},
+	}, holdings.Record{Identifiers: []string{"0006-2499"}, Signature: holdings.Signature{Date: "2001"}})
+	templates := Templates{{Article: []string{"{base}?issn={issn}&year={year}"}}}
+	got, ok := templates.Decision(d, Article{Signature: holdings.Signature{Date: "2001"}})
+	want := Link{URL: "http://example.com/j?issn=0006-2499&year=2001", Level: ArticleLevel}
+	if !ok || got != want {
+		t.Errorf("Decision: got %+v, %v, want %+v", got, ok, want)
+	}
+}
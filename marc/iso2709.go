@@ -0,0 +1,196 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+const (
+	subfieldDelimiter = 0x1f
+	fieldTerminator   = 0x1e
+	recordTerminator  = 0x1d
+	leaderLength      = 24
+)
+
+// Reader reads binary MARC records in ISO 2709.
+type Reader struct {
+	r      *bufio.Reader
+	offset int64
+	next   int64
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Offset returns the byte offset of the record last read.
+func (r *Reader) Offset() int64 {
+	return r.offset
+}
+
+// Read returns the next record. A record, that cannot be parsed, yields an
+// error, but reading can continue with the next record. At the end of the
+// input io.EOF is returned.
+func (r *Reader) Read() (Record, error) {
+	var record Record
+	r.offset = r.next
+	// skip whitespace, e.g. newlines between records
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return record, err
+		}
+		if b != '\n' && b != '\r' && b != ' ' {
+			r.r.UnreadByte()
+			break
+		}
+		r.offset++
+	}
+	data, err := r.r.ReadBytes(recordTerminator)
+	r.next = r.offset + int64(len(data))
+	if err == io.EOF && len(data) > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return record, err
+	}
+	return Parse(data)
+}
+
+// Parse parses a single ISO 2709 record.
+func Parse(data []byte) (Record, error) {
+	var record Record
+	if len(data) < leaderLength {
+		return record, ErrInvalidLeader
+	}
+	record.Leader = string(data[:leaderLength])
+	base, ok := number(data[12:17])
+	if !ok || base <= leaderLength || base > len(data) {
+		return record, ErrInvalidLeader
+	}
+	directory := data[leaderLength : base-1]
+	if len(directory)%12 != 0 {
+		return record, ErrInvalidDirectory
+	}
+	for i := 0; i < len(directory); i += 12 {
+		entry := directory[i : i+12]
+		length, ok := number(entry[3:7])
+		if !ok {
+			return record, ErrInvalidDirectory
+		}
+		start, ok := number(entry[7:12])
+		if !ok {
+			return record, ErrInvalidDirectory
+		}
+		if base+start+length > len(data) || start < 0 || length < 1 {
+			return record, ErrInvalidDirectory
+		}
+		field := Field{Tag: string(entry[:3])}
+		value := bytes.TrimRight(data[base+start:base+start+length], string([]byte{fieldTerminator}))
+		if field.IsControl() {
+			field.Value = string(value)
+			record.Fields = append(record.Fields, field)
+			continue
+		}
+		if len(value) >= 2 {
+			field.Ind1, field.Ind2 = string(value[0]), string(value[1])
+			value = value[2:]
+		}
+		for _, sf := range bytes.Split(value, []byte{subfieldDelimiter}) {
+			if len(sf) == 0 {
+				continue
+			}
+			field.Subfields = append(field.Subfields, Subfield{
+				Code:  string(sf[:1]),
+				Value: string(sf[1:]),
+			})
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record, nil
+}
+
+// number parses a fixed width number of the leader or directory, which may
+// only consist of ASCII digits, so there are no signs or blanks.
+func number(b []byte) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	var n int
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, true
+}
+
+// Writer writes binary MARC records in ISO 2709.
+type Writer struct {
+	w io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes a single record.
+func (w *Writer) Write(record Record) error {
+	b, err := Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(b)
+	return err
+}
+
+// Marshal encodes a record in ISO 2709. Record length and base address of
+// the leader are computed, a missing leader is filled with blanks.
+func Marshal(record Record) ([]byte, error) {
+	var directory, data bytes.Buffer
+	for _, f := range record.Fields {
+		if len(f.Tag) != 3 {
+			return nil, ErrInvalidDirectory
+		}
+		start := data.Len()
+		if f.IsControl() {
+			data.WriteString(f.Value)
+		} else {
+			data.WriteString(blank(f.Ind1)[:1] + blank(f.Ind2)[:1])
+			for _, sf := range f.Subfields {
+				data.WriteByte(subfieldDelimiter)
+				data.WriteString(sf.Code + sf.Value)
+			}
+		}
+		data.WriteByte(fieldTerminator)
+		length := data.Len() - start
+		if length > 9999 || start > 99999 {
+			return nil, ErrInvalidDirectory
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", f.Tag, length, start)
+	}
+	directory.WriteByte(fieldTerminator)
+
+	leader := []byte(record.Leader)
+	if len(leader) < leaderLength {
+		leader = append(leader, bytes.Repeat([]byte(" "), leaderLength-len(leader))...)
+	}
+	leader = leader[:leaderLength]
+	base := leaderLength + directory.Len()
+	total := base + data.Len() + 1
+	if total > 99999 {
+		return nil, ErrInvalidLeader
+	}
+	copy(leader[0:5], fmt.Sprintf("%05d", total))
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+
+	var b bytes.Buffer
+	b.Write(leader)
+	b.Write(directory.Bytes())
+	b.Write(data.Bytes())
+	b.WriteByte(recordTerminator)
+	return b.Bytes(), nil
+}
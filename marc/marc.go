@@ -0,0 +1,91 @@
+// Package marc reads MARC 21 records in ISO 2709 and MARCXML. It covers just
+// enough of MARC to work with holdings and bibliographic linkage.
+package marc
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidLeader    = errors.New("invalid leader")
+	ErrInvalidDirectory = errors.New("invalid directory")
+)
+
+// Subfield is a single subfield of a data field.
+type Subfield struct {
+	Code  string
+	Value string
+}
+
+// Field is a control field, if Value is set, or a data field otherwise.
+type Field struct {
+	Tag       string
+	Ind1      string
+	Ind2      string
+	Value     string
+	Subfields []Subfield
+}
+
+// IsControl returns true for control fields, 001 to 009.
+func (f Field) IsControl() bool {
+	return strings.HasPrefix(f.Tag, "00")
+}
+
+// Subfield returns the value of the first subfield with the given code or
+// the empty string.
+func (f Field) Subfield(code string) string {
+	for _, s := range f.Subfields {
+		if s.Code == code {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// SubfieldValues returns the values of all subfields with the given code.
+func (f Field) SubfieldValues(code string) []string {
+	var values []string
+	for _, s := range f.Subfields {
+		if s.Code == code {
+			values = append(values, s.Value)
+		}
+	}
+	return values
+}
+
+// Record is a MARC record.
+type Record struct {
+	Leader string
+	Fields []Field
+}
+
+// Get returns all fields with one of the given tags, in record order.
+func (r Record) Get(tags ...string) []Field {
+	var fields []Field
+	for _, f := range r.Fields {
+		for _, tag := range tags {
+			if f.Tag == tag {
+				fields = append(fields, f)
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// Control returns the value of the first control field with the given tag
+// or the empty string.
+func (r Record) Control(tag string) string {
+	for _, f := range r.Fields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// ControlNumber returns the value of field 001.
+func (r Record) ControlNumber() string {
+	return strings.TrimSpace(r.Control("001"))
+}
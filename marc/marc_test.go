@@ -0,0 +1,100 @@
+package marc
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// iso builds an ISO 2709 record from tags and raw field data.
+func iso(fields ...[2]string) string {
+	var directory, data string
+	for _, f := range fields {
+		value := f[1] + "\x1e"
+		directory += fmt.Sprintf("%s%04d%05d", f[0], len(value), len(data))
+		data += value
+	}
+	base := 24 + len(directory) + 1
+	leader := fmt.Sprintf("%05dny  a22%05d1n 4500", base+len(data)+1, base)
+	return leader + directory + "\x1e" + data + "\x1d"
+}
+
+func TestRead(t *testing.T) {
+	input := iso([2]string{"001", "123"}, [2]string{"863", "40\x1f81.1\x1fa1-10\x1fi1995-2004"}) +
+		"\n" + "00010broken\x1d" +
+		iso([2]string{"001", "456"})
+
+	r := NewReader(strings.NewReader(input))
+	record, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Record{
+		Leader: record.Leader,
+		Fields: []Field{
+			{Tag: "001", Value: "123"},
+			{Tag: "863", Ind1: "4", Ind2: "0", Subfields: []Subfield{
+				{Code: "8", Value: "1.1"}, {Code: "a", Value: "1-10"}, {Code: "i", Value: "1995-2004"}}},
+		},
+	}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("Read: got %+v, want %+v", record, want)
+	}
+	if _, err := r.Read(); err == nil {
+		t.Error("Read: expected error for broken record")
+	}
+	record, err = r.Read()
+	if err != nil || record.ControlNumber() != "456" {
+		t.Errorf("Read: got %v, %v, want record 456", record.ControlNumber(), err)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read: got %v, want io.EOF", err)
+	}
+}
+
+func TestParseInvalidDirectory(t *testing.T) {
+	var cases = []struct {
+		about string
+		entry string
+	}{
+		{"negative start", "0010004-9999"},
+		{"signed length", "001+00400000"},
+		{"blank start", "0010004    0"},
+		{"start beyond data", "001000400100"},
+	}
+	for _, c := range cases {
+		leader := fmt.Sprintf("%05dny  a22%05d1n 4500", 24+12+1+5, 24+12+1)
+		data := leader + c.entry + "\x1e" + "abc\x1e\x1d"
+		if _, err := Parse([]byte(data)); err != ErrInvalidDirectory {
+			t.Errorf("%s: got %v, want %v", c.about, err, ErrInvalidDirectory)
+		}
+	}
+}
+
+func TestXMLReader(t *testing.T) {
+	doc := `<collection xmlns="http://www.loc.gov/MARC21/slim">
+<record><leader>00000ny  a22000001n 4500</leader>
+<controlfield tag="001">123</controlfield>
+<datafield tag="022" ind1=" " ind2=" "><subfield code="a">0006-2499</subfield></datafield>
+</record>
+</collection>`
+	r := NewXMLReader(strings.NewReader(doc))
+	record, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.ControlNumber() != "123" {
+		t.Errorf("got control number %q, want 123", record.ControlNumber())
+	}
+	if fs := record.Get("022"); len(fs) != 1 || fs[0].Subfield("a") != "0006-2499" {
+		t.Errorf("got 022 %+v", fs)
+	}
+	if r.Line() != 2 {
+		t.Errorf("got line %d, want 2", r.Line())
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read: got %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,155 @@
+package marc
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// Namespace is the MARCXML namespace.
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+// xmlRecord is the MARCXML representation of a record.
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+// XMLReader reads MARCXML records, with or without a collection element.
+type XMLReader struct {
+	decoder *xml.Decoder
+	offset  int64
+	line    int
+}
+
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{decoder: xml.NewDecoder(r)}
+}
+
+// Offset returns the byte offset of the record last read.
+func (r *XMLReader) Offset() int64 {
+	return r.offset
+}
+
+// Line returns the line of the record last read.
+func (r *XMLReader) Line() int {
+	return r.line
+}
+
+// Read returns the next record. A record element, that cannot be decoded,
+// yields an error, but reading can continue. Syntax errors are final. At the
+// end of the input io.EOF is returned.
+func (r *XMLReader) Read() (Record, error) {
+	var record Record
+	for {
+		offset := r.decoder.InputOffset()
+		line, _ := r.decoder.InputPos()
+		t, err := r.decoder.Token()
+		if err != nil {
+			return record, err
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "record" {
+			continue
+		}
+		r.offset, r.line = offset, line
+		var xr xmlRecord
+		if err := r.decoder.DecodeElement(&xr, &se); err != nil {
+			return record, err
+		}
+		record.Leader = xr.Leader
+		// MARCXML keeps control fields before data fields, so does this
+		for _, cf := range xr.ControlFields {
+			record.Fields = append(record.Fields, Field{Tag: cf.Tag, Value: cf.Value})
+		}
+		for _, df := range xr.DataFields {
+			field := Field{Tag: df.Tag, Ind1: df.Ind1, Ind2: df.Ind2}
+			for _, sf := range df.Subfields {
+				field.Subfields = append(field.Subfields, Subfield{Code: sf.Code, Value: sf.Value})
+			}
+			record.Fields = append(record.Fields, field)
+		}
+		return record, nil
+	}
+}
+
+// XMLWriter writes MARCXML records into a collection. Close must be called
+// to end the collection.
+type XMLWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	return &XMLWriter{w: w}
+}
+
+// start writes the XML header and opens the collection.
+func (w *XMLWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	_, err := io.WriteString(w.w, xml.Header+`<collection xmlns="`+Namespace+`">`+"\n")
+	return err
+}
+
+// Write writes a single record.
+func (w *XMLWriter) Write(record Record) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	xr := xmlRecord{Leader: record.Leader}
+	for _, f := range record.Fields {
+		if f.IsControl() {
+			xr.ControlFields = append(xr.ControlFields, xmlControlField{Tag: f.Tag, Value: f.Value})
+			continue
+		}
+		df := xmlDataField{Tag: f.Tag, Ind1: blank(f.Ind1), Ind2: blank(f.Ind2)}
+		for _, sf := range f.Subfields {
+			df.Subfields = append(df.Subfields, xmlSubfield(sf))
+		}
+		xr.DataFields = append(xr.DataFields, df)
+	}
+	b, err := xml.MarshalIndent(xr, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(append(b, '\n'))
+	return err
+}
+
+// Close ends the collection.
+func (w *XMLWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, "</collection>\n")
+	return err
+}
+
+// blank returns an indicator, with a blank for an unset indicator.
+func blank(ind string) string {
+	if ind == "" {
+		return " "
+	}
+	return ind
+}
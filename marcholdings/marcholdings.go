@@ -0,0 +1,452 @@
+// Package marcholdings reads MARC 21 holdings records (MFHD), see
+// http://www.loc.gov/marc/holdings/. Coverage is taken from the 853/863
+// captions and patterns and enumeration and chronology pairs; the textual
+// holdings in 866 are used, if a record has no 863 fields.
+package marcholdings
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/marc"
+)
+
+var (
+	ErrMissingIdentifiers = errors.New("missing identifiers")
+	ErrEnumeration        = errors.New("cannot parse enumeration and chronology")
+)
+
+var (
+	yearPattern = regexp.MustCompile(`^\d{4}`)
+
+	// textual holdings, e.g. v.1:no.2(1995) or 1.1995,2 (ZDB)
+	zdbPattern    = regexp.MustCompile(`^(\d+)\.(\d{4})(?:\s*,\s*(\d+))?`)
+	volumePattern = regexp.MustCompile(`(?i)\b(?:v|vol|bd|jg)\.?\s*(\d+)`)
+	issuePattern  = regexp.MustCompile(`(?i)\b(?:no|nr|h|heft|iss)\.?\s*(\d+)`)
+	datePattern   = regexp.MustCompile(`\b(1[5-9]\d{2}|20\d{2})\b`)
+	numberPattern = regexp.MustCompile(`^\d+$`)
+
+	// zdbIssuePattern matches text, that may be followed by a ZDB issue
+	zdbIssuePattern = regexp.MustCompile(`\d+\.\d{4}\s*$`)
+)
+
+// Reader reads MARC 21 holdings records in ISO 2709 or MARCXML, the
+// encoding is detected from the first byte. Entries are keyed by the ISSNs
+// in 022 of the holdings record and by the ISSNs, that Linkage returns for
+// the control number of the bibliographic record in 004. By default, records
+// without identifiers or with broken structure are skipped, as are 863 and
+// 866 fields, that cannot be parsed; their errors are returned as
+// holdings.ParseError.
+type Reader struct {
+	r       *bufio.Reader
+	Policy  holdings.ErrorPolicy
+	Linkage func(string) []string
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:      bufio.NewReader(r),
+		Policy: holdings.ErrorPolicy{Action: holdings.SkipAndCollect},
+	}
+}
+
+// recordReader is implemented by the ISO 2709 and the MARCXML reader.
+type recordReader interface {
+	Read() (marc.Record, error)
+	Offset() int64
+}
+
+// records returns a reader for the encoding of the input.
+func (r *Reader) records() recordReader {
+	for {
+		b, err := r.r.Peek(1)
+		if err != nil {
+			break
+		}
+		if b[0] == '<' {
+			return marc.NewXMLReader(r.r)
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			break
+		}
+		r.r.ReadByte()
+	}
+	return marc.NewReader(r.r)
+}
+
+// fatal returns true, if reading cannot continue after an error.
+func fatal(err error) bool {
+	if _, ok := err.(*xml.SyntaxError); ok {
+		return true
+	}
+	return err == io.ErrUnexpectedEOF
+}
+
+func (r *Reader) ReadAll() (holdings.Entries, error) {
+	entries := make(holdings.Entries)
+	rr := r.records()
+
+	perr := holdings.ParseError{}
+
+	for {
+		record, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if fatal(err) {
+				return entries, err
+			}
+			if err := r.Policy.Handle(&perr, &holdings.RecordError{Err: err}); err != nil {
+				return entries, err
+			}
+			continue
+		}
+		ids := r.identifiers(record)
+		if len(ids) == 0 {
+			rerr := &holdings.RecordError{Record: record.ControlNumber(), Err: ErrMissingIdentifiers}
+			if err := r.Policy.Handle(&perr, rerr); err != nil {
+				return entries, err
+			}
+			continue
+		}
+		parsed, issues := parse(record)
+		var stop error
+		for _, is := range issues {
+			if is.rule != RuleEnumeration {
+				continue
+			}
+			rerr := &holdings.RecordError{
+				Record:  record.ControlNumber(),
+				Snippet: is.value,
+				Err:     fmt.Errorf("%w: %s: %s", ErrEnumeration, is.path, is.message),
+			}
+			if stop = r.Policy.Handle(&perr, rerr); stop != nil {
+				break
+			}
+		}
+		if stop != nil {
+			return entries, stop
+		}
+		for _, entry := range parsed {
+			for _, id := range ids {
+				entries[id] = append(entries[id], entry)
+			}
+		}
+	}
+	if len(perr.Errors) > 0 {
+		return entries, perr
+	}
+	return entries, nil
+}
+
+// Links maps control numbers of bibliographic records to their ISSNs, for
+// use as Linkage.
+type Links map[string][]string
+
+// ISSNs returns the ISSNs of a bibliographic record.
+func (l Links) ISSNs(id string) []string {
+	return l[id]
+}
+
+// ReadLinks reads tab separated lines with the control number of a
+// bibliographic record followed by one or more ISSNs, e.g. from a catalog
+// export of 001 and 022.
+func ReadLinks(r io.Reader) (Links, error) {
+	links := make(Links)
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		fields := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+		if id := strings.TrimSpace(fields[0]); id != "" {
+			for _, issn := range fields[1:] {
+				if issn = strings.TrimSpace(issn); issn != "" {
+					links[id] = append(links[id], issn)
+				}
+			}
+		}
+		if err == io.EOF {
+			return links, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// identifiers returns the ISSNs of a holdings record.
+func (r *Reader) identifiers(record marc.Record) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(s string) {
+		if issn, ok := holdings.NormalizeISSN(s); ok && !seen[issn] {
+			seen[issn] = true
+			ids = append(ids, issn)
+		}
+	}
+	for _, f := range record.Get("022") {
+		add(f.Subfield("a"))
+	}
+	if r.Linkage != nil {
+		if link := strings.TrimSpace(record.Control("004")); link != "" {
+			for _, issn := range r.Linkage(link) {
+				add(issn)
+			}
+		}
+	}
+	return ids
+}
+
+// caption records, which subfields of an 863 field carry volume, issue,
+// year, month and day, as declared by the matching 853 field.
+type caption struct {
+	volume, issue, year, month, day string
+}
+
+// defaultCaption is used, if there is no matching 853 field.
+var defaultCaption = caption{volume: "a", issue: "b", year: "i", month: "j", day: "k"}
+
+// parseCaption reads an 853 field. The first two enumeration levels become
+// volume and issue. Chronology captions are parenthesized, e.g. (year), and
+// recognized by their wording; they may also appear on enumeration levels,
+// if chronology is used as enumeration.
+func parseCaption(f marc.Field) caption {
+	var c caption
+	var levels int
+	for _, sf := range f.Subfields {
+		label := strings.ToLower(strings.TrimSpace(sf.Value))
+		switch {
+		case sf.Code == "":
+			continue
+		case strings.Contains("ijkl", sf.Code) || strings.HasPrefix(label, "("):
+			switch {
+			case strings.Contains(label, "year") || strings.Contains(label, "jahr"):
+				c.year = sf.Code
+			case strings.Contains(label, "month") || strings.Contains(label, "monat") ||
+				strings.Contains(label, "season"):
+				c.month = sf.Code
+			case strings.Contains(label, "day") || strings.Contains(label, "tag"):
+				c.day = sf.Code
+			}
+		case strings.Contains("abcdef", sf.Code):
+			levels++
+			switch levels {
+			case 1:
+				c.volume = sf.Code
+			case 2:
+				c.issue = sf.Code
+			}
+		}
+	}
+	return c
+}
+
+// issue is a problem found while parsing a record.
+type issue struct {
+	rule, path, value, message string
+}
+
+// linkNumber returns the link number of a subfield 8, e.g. 1 for 1.3.
+func linkNumber(f marc.Field) string {
+	return strings.SplitN(f.Subfield("8"), ".", 2)[0]
+}
+
+// parse turns the 863 or 866 fields of a record into entries.
+func parse(record marc.Record) ([]holdings.Entry, []issue) {
+	var entries []holdings.Entry
+	var issues []issue
+
+	captions := make(map[string]caption)
+	for _, f := range record.Get("853") {
+		captions[linkNumber(f)] = parseCaption(f)
+	}
+	fields := record.Get("863")
+	for i, f := range fields {
+		path := fmt.Sprintf("/863[%d]", i+1)
+		c, ok := captions[linkNumber(f)]
+		if !ok {
+			issues = append(issues, issue{RuleCaption, path + "/8", f.Subfield("8"), "no matching 853 field"})
+			c = defaultCaption
+		}
+		entry, code, err := parsePattern(f, c)
+		if err != nil {
+			issues = append(issues, issue{RuleEnumeration, path + "/" + code, f.Subfield(code), err.Error()})
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(fields) > 0 {
+		return entries, issues
+	}
+	for i, f := range record.Get("866") {
+		for _, text := range f.SubfieldValues("a") {
+			parsed, err := parseTextual(text)
+			if err != nil {
+				issues = append(issues, issue{RuleEnumeration, fmt.Sprintf("/866[%d]/a", i+1), text, err.Error()})
+				continue
+			}
+			for _, e := range parsed {
+				e.Comment = strings.TrimSpace(f.Subfield("z"))
+				entries = append(entries, e)
+			}
+		}
+	}
+	return entries, issues
+}
+
+// split splits an 863 value into begin and end. An open range, like 1995-,
+// has an empty end and open is true.
+func split(s string) (begin, end string, open bool) {
+	s = strings.TrimSpace(s)
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) == 1 {
+		return s, s, false
+	}
+	begin, end = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	return begin, end, end == ""
+}
+
+// parsePattern reads an 863 field according to its caption. On error, the
+// code of the offending subfield is returned.
+func parsePattern(f marc.Field, c caption) (holdings.Entry, string, error) {
+	var entry holdings.Entry
+	// a range is open, if no level has an end, e.g. 10- and 2009-; a level
+	// may lack an end in a closed range, e.g. 123- with 10-12
+	var open, closed bool
+
+	value := func(code string) (string, string) {
+		if code == "" || f.Subfield(code) == "" {
+			return "", ""
+		}
+		begin, end, o := split(f.Subfield(code))
+		open, closed = open || o, closed || !o
+		return begin, end
+	}
+
+	yb, ye := value(c.year)
+	for _, y := range []string{yb, ye} {
+		if y != "" && !yearPattern.MatchString(y) {
+			return entry, c.year, fmt.Errorf("invalid year")
+		}
+	}
+	mb, me := value(c.month)
+	db, de := value(c.day)
+	entry.Begin.Date = date(yb, mb, db)
+	entry.End.Date = date(ye, me, de)
+	entry.Begin.Volume, entry.End.Volume = value(c.volume)
+	entry.Begin.Issue, entry.End.Issue = value(c.issue)
+	if open && !closed {
+		entry.End = holdings.Signature{}
+	}
+	if entry.Begin == (holdings.Signature{}) && entry.End == (holdings.Signature{}) {
+		return entry, c.volume, fmt.Errorf("no enumeration or chronology")
+	}
+	entry.Comment = strings.TrimSpace(f.Subfield("z"))
+	for _, note := range f.SubfieldValues("x") {
+		if d, disallowEarlier, ok := parseWall(note); ok {
+			entry.Embargo, entry.EmbargoDisallowEarlier = d, disallowEarlier
+		}
+	}
+	return entry, "", nil
+}
+
+// date builds a date from year, month and day, as far as they are valid.
+// Seasons and other codes are left out.
+func date(year, month, day string) string {
+	if len(year) < 4 {
+		return ""
+	}
+	s := year[:4]
+	m, err := strconv.Atoi(month)
+	if err != nil || m < 1 || m > 12 {
+		return s
+	}
+	s = fmt.Sprintf("%s-%02d", s, m)
+	d, err := strconv.Atoi(day)
+	if err != nil || d < 1 || d > 31 {
+		return s
+	}
+	return fmt.Sprintf("%s-%02d", s, d)
+}
+
+// parseTextual parses textual holdings like "v.1(1995)-v.10(2004),v.12(2006)-"
+// or in ZDB style "1.1995 - 10.2004; 12.2006 -".
+func parseTextual(s string) ([]holdings.Entry, error) {
+	var entries []holdings.Entry
+	for _, segment := range segments(s) {
+		segment = strings.TrimSpace(segment)
+		if segment == "" {
+			continue
+		}
+		begin, end, open := split(segment)
+		var entry holdings.Entry
+		var err error
+		if entry.Begin, err = parseStatement(begin); err != nil {
+			return nil, err
+		}
+		if !open {
+			if entry.End, err = parseStatement(end); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// segments splits textual holdings at semicolons and at commas, which mark a
+// gap in Z39.71. The comma before an issue in ZDB style, e.g. 1.1995,2, does
+// not split.
+func segments(s string) []string {
+	var result []string
+	var start int
+	for i, c := range s {
+		switch c {
+		case ';':
+		case ',':
+			if zdbIssuePattern.MatchString(s[start:i]) {
+				continue
+			}
+		default:
+			continue
+		}
+		result = append(result, s[start:i])
+		start = i + 1
+	}
+	return append(result, s[start:])
+}
+
+// parseStatement parses one side of a textual holdings range.
+func parseStatement(s string) (holdings.Signature, error) {
+	var sig holdings.Signature
+	s = strings.TrimSpace(s)
+	if ms := zdbPattern.FindStringSubmatch(s); ms != nil {
+		return holdings.Signature{Volume: ms[1], Date: ms[2], Issue: ms[3]}, nil
+	}
+	if numberPattern.MatchString(s) {
+		if len(s) == 4 {
+			return holdings.Signature{Date: s}, nil
+		}
+		return holdings.Signature{Volume: s}, nil
+	}
+	if ms := volumePattern.FindStringSubmatch(s); ms != nil {
+		sig.Volume = ms[1]
+	}
+	if ms := issuePattern.FindStringSubmatch(s); ms != nil {
+		sig.Issue = ms[1]
+	}
+	if ms := datePattern.FindStringSubmatch(s); ms != nil {
+		sig.Date = ms[1]
+	}
+	if sig == (holdings.Signature{}) {
+		return sig, fmt.Errorf("cannot parse textual holdings")
+	}
+	return sig, nil
+}
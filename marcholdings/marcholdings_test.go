@@ -0,0 +1,149 @@
+package marcholdings
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miku/holdings"
+)
+
+const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+<record>
+  <leader>00000ny  a22000001n 4500</leader>
+  <controlfield tag="001">h1</controlfield>
+  <controlfield tag="004">b1</controlfield>
+  <datafield tag="853" ind1="2" ind2="0">
+    <subfield code="8">1</subfield><subfield code="a">v.</subfield>
+    <subfield code="b">no.</subfield><subfield code="i">(year)</subfield>
+  </datafield>
+  <datafield tag="863" ind1="4" ind2="0">
+    <subfield code="8">1.1</subfield><subfield code="a">10-12</subfield>
+    <subfield code="b">123-234</subfield><subfield code="i">2009-2011</subfield>
+  </datafield>
+  <datafield tag="863" ind1="4" ind2="0">
+    <subfield code="8">1.2</subfield><subfield code="a">15-</subfield>
+    <subfield code="i">2014-</subfield>
+  </datafield>
+</record>
+<record>
+  <leader>00000ny  a22000001n 4500</leader>
+  <controlfield tag="001">h2</controlfield>
+  <datafield tag="022" ind1=" " ind2=" "><subfield code="a">1613-4141</subfield></datafield>
+  <datafield tag="866" ind1=" " ind2="0">
+    <subfield code="a">1.1995 - 10.2004; 12.2006 -</subfield><subfield code="z">Print</subfield>
+  </datafield>
+</record>
+<record>
+  <leader>00000ny  a22000001n 4500</leader>
+  <controlfield tag="001">h3</controlfield>
+</record>
+</collection>`
+
+func TestReadAll(t *testing.T) {
+	r := NewReader(strings.NewReader(doc))
+	r.Linkage = func(id string) []string {
+		if id == "b1" {
+			return []string{"00062499"}
+		}
+		return nil
+	}
+	entries, err := r.ReadAll()
+
+	var perr holdings.ParseError
+	if !errors.As(err, &perr) || len(perr.Errors) != 1 {
+		t.Fatalf("ReadAll: got %v, want a single error for h3", err)
+	}
+
+	want := holdings.Entries{
+		"0006-2499": []holdings.License{
+			holdings.Entry{
+				Begin: holdings.Signature{Date: "2009", Volume: "10", Issue: "123"},
+				End:   holdings.Signature{Date: "2011", Volume: "12", Issue: "234"},
+			},
+			holdings.Entry{Begin: holdings.Signature{Date: "2014", Volume: "15"}},
+		},
+		"1613-4141": []holdings.License{
+			holdings.Entry{
+				Begin:   holdings.Signature{Date: "1995", Volume: "1"},
+				End:     holdings.Signature{Date: "2004", Volume: "10"},
+				Comment: "Print",
+			},
+			holdings.Entry{Begin: holdings.Signature{Date: "2006", Volume: "12"}, Comment: "Print"},
+		},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ReadAll: got %+v, want %+v", entries, want)
+	}
+}
+
+func TestReadAllEnumeration(t *testing.T) {
+	doc := `<collection xmlns="http://www.loc.gov/MARC21/slim"><record>
+  <controlfield tag="001">h1</controlfield>
+  <datafield tag="022" ind1=" " ind2=" "><subfield code="a">1613-4141</subfield></datafield>
+  <datafield tag="866" ind1=" " ind2="0"><subfield code="a">whatever</subfield></datafield>
+</record></collection>`
+	_, err := NewReader(strings.NewReader(doc)).ReadAll()
+	var rerr *holdings.RecordError
+	if !errors.As(err, &rerr) || !errors.Is(err, ErrEnumeration) || rerr.Record != "h1" || rerr.Snippet != "whatever" {
+		t.Errorf("ReadAll: got %v, want an enumeration error for h1", err)
+	}
+}
+
+func TestReadLinks(t *testing.T) {
+	links, err := ReadLinks(strings.NewReader("b1\t0006-2499\t1521-4036\n\nb2\t1613-4141"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Links{"b1": {"0006-2499", "1521-4036"}, "b2": {"1613-4141"}}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("ReadLinks: got %v, want %v", links, want)
+	}
+}
+
+func TestParseTextual(t *testing.T) {
+	var cases = []struct {
+		s    string
+		want []holdings.Entry
+	}{
+		{"1995-2003", []holdings.Entry{{
+			Begin: holdings.Signature{Date: "1995"}, End: holdings.Signature{Date: "2003"}}}},
+		{"v.1:no.2(1995)-v.10(2004)", []holdings.Entry{{
+			Begin: holdings.Signature{Date: "1995", Volume: "1", Issue: "2"},
+			End:   holdings.Signature{Date: "2004", Volume: "10"}}}},
+		{"5.2001,3 -", []holdings.Entry{{
+			Begin: holdings.Signature{Date: "2001", Volume: "5", Issue: "3"}}}},
+	}
+	for _, c := range cases {
+		got, err := parseTextual(c.s)
+		if err != nil {
+			t.Errorf("parseTextual(%q): %v", c.s, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseTextual(%q): got %+v, want %+v", c.s, got, c.want)
+		}
+	}
+	if _, err := parseTextual("unknown"); err == nil {
+		t.Error("parseTextual: expected error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	report, err := NewReader(strings.NewReader(doc)).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 3 {
+		t.Errorf("got %d records, want 3", report.Records)
+	}
+	var rules []string
+	for _, p := range report.Problems {
+		rules = append(rules, p.Rule+" "+p.Path)
+	}
+	want := []string{"identifier /record[1]", "identifier /record[3]", "coverage /record[3]"}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("Validate: got %v, want %v", rules, want)
+	}
+}
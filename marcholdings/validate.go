@@ -0,0 +1,77 @@
+package marcholdings
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/miku/holdings"
+)
+
+// Rule names, see Rules for a description.
+const (
+	RuleDecode      = "decode"
+	RuleIdentifier  = "identifier"
+	RuleISSN        = "issn"
+	RuleCaption     = "caption"
+	RuleEnumeration = "enumeration"
+	RuleCoverage    = "coverage"
+	RuleDateOrder   = "date-order"
+)
+
+// Rules is the catalog of checks performed by Validate.
+var Rules = holdings.Catalog{
+	{Name: RuleDecode, Severity: holdings.Error, Description: "record must be valid ISO 2709 or MARCXML"},
+	{Name: RuleIdentifier, Severity: holdings.Error, Description: "record must have an ISSN in 022 or via the linkage in 004"},
+	{Name: RuleISSN, Severity: holdings.Error, Description: "022 $a must be an ISSN with a valid check digit"},
+	{Name: RuleCaption, Severity: holdings.Warning, Description: "863 must link to an 853 caption via $8"},
+	{Name: RuleEnumeration, Severity: holdings.Warning, Description: "863 and 866 values must be understood"},
+	{Name: RuleCoverage, Severity: holdings.Warning, Description: "record should have coverage in 863 or 866"},
+	{Name: RuleDateOrder, Severity: holdings.Error, Description: "coverage must not begin after it ends"},
+}
+
+// Validate reads the remaining input and checks every record. Problems are
+// located by record number, field and the byte offset of the record. The
+// returned error is only non-nil for I/O errors.
+func (r *Reader) Validate() (holdings.Report, error) {
+	v := holdings.Collector{Rules: Rules}
+	rr := r.records()
+
+	for n := 1; ; n++ {
+		record, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		v.At = holdings.Problem{Offset: rr.Offset(), Path: fmt.Sprintf("/record[%d]", n)}
+		if err != nil {
+			v.Add(RuleDecode, "", "", err.Error())
+			if fatal(err) {
+				return v.Report, nil
+			}
+			continue
+		}
+		v.Report.Records++
+
+		for i, f := range record.Get("022") {
+			issn := f.Subfield("a")
+			if n, _ := holdings.NormalizeISSN(issn); issn != "" && !holdings.ValidISSN(n) {
+				v.Add(RuleISSN, fmt.Sprintf("/022[%d]/a", i+1), issn, "invalid ISSN")
+			}
+		}
+		if len(r.identifiers(record)) == 0 {
+			v.Add(RuleIdentifier, "", record.ControlNumber(), "no ISSN found")
+		}
+		entries, issues := parse(record)
+		for _, is := range issues {
+			v.Add(is.rule, is.path, is.value, is.message)
+		}
+		if len(entries) == 0 && len(issues) == 0 {
+			v.Add(RuleCoverage, "", record.ControlNumber(), "no coverage")
+		}
+		for _, e := range entries {
+			if e.Begin.Date != "" && e.End.Date != "" && e.Begin.Date > e.End.Date {
+				v.Add(RuleDateOrder, "", e.End.Date, "end before begin "+e.Begin.Date)
+			}
+		}
+	}
+	return v.Report, nil
+}
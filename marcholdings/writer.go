@@ -0,0 +1,300 @@
+package marcholdings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/marc"
+)
+
+// leader of a serial item holdings record at holdings level 4.
+const leader = "00000ny  a22000004n 4500"
+
+// Location is the 852 location data of a holdings source.
+type Location struct {
+	Institution string `json:"institution,omitempty"` // $a, e.g. an ISIL
+	Sublocation string `json:"sublocation,omitempty"` // $b
+	Shelving    string `json:"shelving,omitempty"`    // $c
+	CallNumber  string `json:"call_number,omitempty"` // $h
+	Note        string `json:"note,omitempty"`        // $z
+	URL         string `json:"url,omitempty"`         // 856 $u
+}
+
+// Locations maps source names to locations.
+type Locations map[string]Location
+
+// Lookup returns the location of a source, or the location named default.
+// Without either, the source name is used as sublocation, so a record always
+// has an 852 field.
+func (l Locations) Lookup(source string) Location {
+	if loc, ok := l[source]; ok {
+		return loc
+	}
+	if loc, ok := l["default"]; ok {
+		return loc
+	}
+	return Location{Sublocation: source}
+}
+
+// ReadLocations reads locations from a JSON object keyed by source name.
+func ReadLocations(r io.Reader) (Locations, error) {
+	var l Locations
+	if err := json.NewDecoder(r).Decode(&l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// recordWriter is implemented by the ISO 2709 and the MARCXML writer.
+type recordWriter interface {
+	Write(marc.Record) error
+}
+
+// Writer writes MARC 21 holdings records from holdings entries. For each
+// title, a record with its ISSNs in 022, location data in 852, coverage in
+// 853/863 pairs and a summary in 866 is written. A moving wall goes into a
+// nonpublic note of the 863 field in KBART notation, e.g. "embargo P1Y",
+// which the Reader reads back. The control number in 001 is the first ISSN,
+// preceded by Prefix. If Linkage is set, it returns the control number of
+// the bibliographic record for 004.
+type Writer struct {
+	w        recordWriter
+	Location Location
+	Prefix   string
+	Linkage  func(string) string
+}
+
+// NewWriter returns a writer for ISO 2709.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: marc.NewWriter(w)}
+}
+
+// NewXMLWriter returns a writer for MARCXML, which must be closed.
+func NewXMLWriter(w io.Writer) *Writer {
+	return &Writer{w: marc.NewXMLWriter(w)}
+}
+
+// Write writes a record for an identifier. Identifiers without coverage are
+// skipped.
+func (w *Writer) Write(id string, licenses []holdings.License) error {
+	record, ok := w.Record(id, licenses)
+	if !ok {
+		return nil
+	}
+	return w.w.Write(record)
+}
+
+// WriteAll writes a record for each title, sorted by identifier. A title
+// listed under several ISSNs, e.g. print and online, gets a single record
+// with all its ISSNs, if the licenses of the ISSNs are the same and name the
+// title.
+func (w *Writer) WriteAll(entries holdings.Entries) error {
+	for _, ids := range titles(entries) {
+		record, ok := w.record(ids, holdings.Normalize(entries[ids[0]]))
+		if !ok {
+			continue
+		}
+		if err := w.w.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// titles groups the identifiers of entries, that have the same licenses
+// with a title. Other identifiers stay on their own.
+func titles(entries holdings.Entries) [][]string {
+	var ids []string
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	// candidates have the same title and number of licenses
+	type key struct {
+		title string
+		n     int
+	}
+	var groups [][]string
+	candidates := make(map[key][]int)
+	for _, id := range ids {
+		licenses := entries[id]
+		var k key
+		if k.n = len(licenses); k.n > 0 {
+			k.title = holdings.TitleOf(licenses[0])
+		}
+		found := -1
+		if k.title != "" {
+			for _, i := range candidates[k] {
+				if reflect.DeepEqual(entries[groups[i][0]], licenses) {
+					found = i
+					break
+				}
+			}
+		}
+		if found < 0 {
+			found = len(groups)
+			groups = append(groups, nil)
+			candidates[k] = append(candidates[k], found)
+		}
+		groups[found] = append(groups[found], id)
+	}
+	return groups
+}
+
+// Close ends the output, if necessary.
+func (w *Writer) Close() error {
+	if c, ok := w.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Record builds the holdings record for an identifier and reports, whether
+// there is any coverage.
+func (w *Writer) Record(id string, licenses []holdings.License) (marc.Record, bool) {
+	return w.record([]string{id}, holdings.Normalize(licenses))
+}
+
+// record builds the holdings record for the identifiers of a title.
+func (w *Writer) record(ids []string, coverage []holdings.Entry) (marc.Record, bool) {
+	record := marc.Record{Leader: leader}
+	if len(coverage) == 0 {
+		return record, false
+	}
+	add := func(tag, ind1, ind2 string, subfields ...string) {
+		f := marc.Field{Tag: tag, Ind1: ind1, Ind2: ind2}
+		for i := 0; i+1 < len(subfields); i += 2 {
+			if subfields[i+1] != "" {
+				f.Subfields = append(f.Subfields, marc.Subfield{Code: subfields[i], Value: subfields[i+1]})
+			}
+		}
+		if len(f.Subfields) > 0 {
+			record.Fields = append(record.Fields, f)
+		}
+	}
+
+	record.Fields = append(record.Fields, marc.Field{Tag: "001", Value: w.Prefix + ids[0]})
+	if w.Linkage != nil {
+		for _, id := range ids {
+			if link := w.Linkage(id); link != "" {
+				record.Fields = append(record.Fields, marc.Field{Tag: "004", Value: link})
+				break
+			}
+		}
+	}
+	for _, id := range ids {
+		add("022", " ", " ", "a", id)
+	}
+	loc := w.Location
+	add("852", " ", " ", "a", loc.Institution, "b", loc.Sublocation, "c", loc.Shelving,
+		"h", loc.CallNumber, "z", loc.Note)
+	add("853", "2", "0", "8", "1", "a", "v.", "b", "no.", "i", "(year)", "j", "(month)", "k", "(day)")
+
+	var summary []string
+	for i, e := range coverage {
+		add("863", "4", "0", "8", fmt.Sprintf("1.%d", i+1),
+			"a", enumeration(e, volumeOf),
+			"b", enumeration(e, issueOf),
+			"i", enumeration(e, datePart(0)),
+			"j", enumeration(e, datePart(1)),
+			"k", enumeration(e, datePart(2)),
+			"x", movingWall(e))
+		summary = append(summary, textual(e))
+	}
+	add("866", " ", "0", "8", "0", "a", strings.Join(summary, ","))
+	if loc.URL != "" {
+		add("856", "4", "0", "u", loc.URL)
+	}
+	return record, true
+}
+
+func volumeOf(s holdings.Signature) string { return s.Volume }
+
+func issueOf(s holdings.Signature) string { return s.Issue }
+
+// datePart returns a function, that returns year, month or day of a date.
+func datePart(i int) func(holdings.Signature) string {
+	return func(s holdings.Signature) string {
+		parts := strings.Split(s.Date, "-")
+		if i < len(parts) {
+			return parts[i]
+		}
+		return ""
+	}
+}
+
+// enumeration writes an 863 value for one level, e.g. 10-12, 10- or 10. A
+// level, that only one side of a closed range has, keeps the other side
+// empty, e.g. 123- for an issue at the beginning only; the range is still
+// closed by the other levels.
+func enumeration(e holdings.Entry, get func(holdings.Signature) string) string {
+	begin, end := get(e.Begin), get(e.End)
+	switch {
+	case begin == "" && end == "":
+		return ""
+	case e.End == holdings.Signature{}:
+		return begin + "-"
+	case begin == end:
+		return begin
+	default:
+		return begin + "-" + end
+	}
+}
+
+// wallPattern matches the moving wall note, that movingWall writes.
+var wallPattern = regexp.MustCompile(`^embargo ([PR]\d+[YMD])$`)
+
+// movingWall returns a nonpublic note on the moving wall of an entry.
+func movingWall(e holdings.Entry) string {
+	if emb := holdings.FormatEmbargo(e.Embargo, e.EmbargoDisallowEarlier); emb != "" {
+		return "embargo " + emb
+	}
+	return ""
+}
+
+// parseWall reads a moving wall note, as written by movingWall.
+func parseWall(s string) (d time.Duration, disallowEarlier bool, ok bool) {
+	ms := wallPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if ms == nil {
+		return 0, false, false
+	}
+	v := ms[1]
+	n, err := strconv.Atoi(v[1 : len(v)-1])
+	if err != nil {
+		return 0, false, false
+	}
+	unit := map[byte]time.Duration{'Y': holdings.Year, 'M': holdings.Month, 'D': holdings.Day}[v[len(v)-1]]
+	return -time.Duration(n) * unit, v[0] == 'R', true
+}
+
+// textual writes an entry as textual holdings in Z39.71 notation, e.g.
+// v.10:no.123(2009)-v.12(2011).
+func textual(e holdings.Entry) string {
+	side := func(s holdings.Signature) string {
+		var parts []string
+		if s.Volume != "" {
+			parts = append(parts, "v."+s.Volume)
+		}
+		if s.Issue != "" {
+			parts = append(parts, "no."+s.Issue)
+		}
+		t := strings.Join(parts, ":")
+		if s.Date != "" {
+			t += "(" + s.Date + ")"
+		}
+		return t
+	}
+	begin, end := side(e.Begin), side(e.End)
+	if begin == end {
+		return begin
+	}
+	return begin + "-" + end
+}
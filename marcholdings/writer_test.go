@@ -0,0 +1,133 @@
+package marcholdings
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/marc"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	entries := holdings.Entries{
+		"0006-2499": []holdings.License{
+			holdings.Entry{
+				Begin: holdings.Signature{Date: "2009-03", Volume: "10", Issue: "123"},
+				End:   holdings.Signature{Date: "2011", Volume: "12"},
+			},
+			holdings.Entry{Begin: holdings.Signature{Date: "2014", Volume: "15"}, Embargo: -1 * holdings.Year},
+		},
+	}
+	for _, encoding := range []string{"iso", "xml"} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		if encoding == "xml" {
+			w = NewXMLWriter(&buf)
+		}
+		w.Location = Location{Institution: "DE-15", CallNumber: "Online"}
+		if err := w.WriteAll(entries); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", encoding, err)
+		}
+		want := holdings.Entries{
+			"0006-2499": []holdings.License{
+				holdings.Entry{
+					Begin: holdings.Signature{Date: "2009-03", Volume: "10", Issue: "123"},
+					End:   holdings.Signature{Date: "2011", Volume: "12"},
+				},
+				holdings.Entry{Begin: holdings.Signature{Date: "2014", Volume: "15"}, Embargo: -1 * holdings.Year},
+			},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", encoding, got, want)
+		}
+	}
+}
+
+func TestWriteAllTitles(t *testing.T) {
+	things := []holdings.License{holdings.Entry{Begin: holdings.Signature{Date: "1995"}, Title: "Journal of Things"}}
+	stuff := []holdings.License{holdings.Entry{Begin: holdings.Signature{Date: "1995"}, Title: "Annals of Stuff"}}
+	entries := holdings.Entries{"0006-2499": things, "1613-4141": things, "2345-6789": stuff}
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).WriteAll(entries); err != nil {
+		t.Fatal(err)
+	}
+	r := marc.NewReader(&buf)
+	var got [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var issns []string
+		for _, f := range record.Get("022") {
+			issns = append(issns, f.Subfield("a"))
+		}
+		got = append(got, issns)
+	}
+	want := [][]string{{"0006-2499", "1613-4141"}, {"2345-6789"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteAll: got records with ISSNs %v, want %v", got, want)
+	}
+}
+
+func TestLocationsLookup(t *testing.T) {
+	locs := Locations{"springer": {Institution: "DE-15"}}
+	if loc := locs.Lookup("springer"); loc.Institution != "DE-15" {
+		t.Errorf("Lookup: got %+v", loc)
+	}
+	if loc := locs.Lookup("other"); loc != (Location{Sublocation: "other"}) {
+		t.Errorf("Lookup: got %+v, want the source as sublocation", loc)
+	}
+	locs["default"] = Location{Institution: "DE-14"}
+	if loc := locs.Lookup("other"); loc.Institution != "DE-14" {
+		t.Errorf("Lookup: got %+v, want default", loc)
+	}
+}
+
+func TestWriterRecord(t *testing.T) {
+	w := Writer{Prefix: "ezb-", Location: Location{Institution: "DE-15"}}
+	record, ok := w.Record("0006-2499", []holdings.License{
+		holdings.Entry{Begin: holdings.Signature{Date: "1995"}, End: holdings.Signature{Date: "2003"}},
+		holdings.Entry{Begin: holdings.Signature{Date: "2005", Volume: "11"}},
+	})
+	if !ok {
+		t.Fatal("Record: expected coverage")
+	}
+	if record.ControlNumber() != "ezb-0006-2499" {
+		t.Errorf("got control number %q", record.ControlNumber())
+	}
+	if f := record.Get("852"); len(f) != 1 || f[0].Subfield("a") != "DE-15" {
+		t.Errorf("got 852 %+v", f)
+	}
+	if f := record.Get("866"); len(f) != 1 || f[0].Subfield("a") != "(1995)-(2003),v.11(2005)-" {
+		t.Errorf("got 866 %+v", f)
+	}
+	if f := record.Get("863"); len(f) != 2 || f[1].Subfield("a") != "11-" || f[1].Subfield("i") != "2005-" {
+		t.Errorf("got 863 %+v", f)
+	}
+	if _, ok := w.Record("0006-2499", nil); ok {
+		t.Error("Record: expected no record without coverage")
+	}
+
+	b, err := marc.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "00") || b[len(b)-1] != 0x1d {
+		t.Errorf("Marshal: unexpected record %q", b)
+	}
+}
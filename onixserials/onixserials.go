@@ -0,0 +1,343 @@
+// Package onixserials reads ONIX for Serials Online Holdings (SOH)
+// messages. A holding is an OnlineSerialHoldings element, identified by the
+// ISSNs of its serial versions:
+//
+//	<OnlineSerialHoldings>
+//	  <SerialWork><Title><TitleText>...</TitleText></Title></SerialWork>
+//	  <SerialVersion>
+//	    <ProductIdentifier><ProductIDType>07</ProductIDType><IDValue>1234-5678</IDValue></ProductIdentifier>
+//	  </SerialVersion>
+//	  <Coverage>
+//	    <FixedCoverage><From>...</From><To>...</To></FixedCoverage>
+//	    <MovingCoverage>...</MovingCoverage>
+//	  </Coverage>
+//	</OnlineSerialHoldings>
+//
+// Fixed coverage lists its first and last issue in From and To, FirstIssue
+// and LastIssue, or as a sequence of Release elements. An issue carries
+// Enumeration levels and a NominalDate. Moving coverage is a Period with a
+// Unit and a Number; MovingCoverageType 02 means only the most recent
+// content is available, anything else is an embargo. Several moving
+// coverages of a statement all apply: the longest embargo or the shortest
+// rolling period holds. Embargo and rolling period together cannot be
+// expressed and the holding is rejected.
+package onixserials
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/internal/snippet"
+)
+
+var (
+	ErrInvalidPeriod      = errors.New("invalid period")
+	ErrInvalidDate        = errors.New("invalid date")
+	ErrMissingIdentifiers = errors.New("missing identifiers")
+	ErrConflictingWalls   = errors.New("embargo and rolling moving coverage")
+)
+
+var datePattern = regexp.MustCompile(`^(\d{4})-?(\d{2})?-?(\d{2})?$`)
+
+// Holding is the holding of a single serial.
+type Holding struct {
+	Title    []string   `xml:"SerialWork>Title>TitleText"`
+	Versions []Version  `xml:"SerialVersion"`
+	Coverage []Coverage `xml:"Coverage"`
+	Online   []Coverage `xml:"OnlineHoldings>Coverage"`
+}
+
+// Version is a print or online version of a serial.
+type Version struct {
+	Identifiers []Identifier `xml:"ProductIdentifier"`
+	ProductForm string       `xml:"ProductForm"`
+}
+
+// Identifier is a product identifier.
+type Identifier struct {
+	Type  string `xml:"ProductIDType"`
+	Value string `xml:"IDValue"`
+}
+
+// Coverage is a coverage statement.
+type Coverage struct {
+	Fixed  []FixedCoverage  `xml:"FixedCoverage"`
+	Moving []MovingCoverage `xml:"MovingCoverage"`
+}
+
+// FixedCoverage is a range of issues.
+type FixedCoverage struct {
+	From       *Release  `xml:"From"`
+	To         *Release  `xml:"To"`
+	FirstIssue *Release  `xml:"FirstIssue"`
+	LastIssue  *Release  `xml:"LastIssue"`
+	Releases   []Release `xml:"Release"`
+}
+
+// Release is a single issue, given by enumeration and chronology.
+type Release struct {
+	Enumeration Enumeration `xml:"Enumeration"`
+	Date        string      `xml:"NominalDate>Calendar>Date"`
+	PlainDate   string      `xml:"NominalDate>Date"`
+}
+
+// Enumeration holds the levels Level1, Level2 and so on.
+type Enumeration struct {
+	Levels []Level `xml:",any"`
+}
+
+// Level is a level of enumeration, e.g. volume or issue.
+type Level struct {
+	XMLName xml.Name
+	Unit    string `xml:"Unit"`
+	Number  string `xml:"Number"`
+}
+
+// MovingCoverage is a moving wall.
+type MovingCoverage struct {
+	Type   string `xml:"MovingCoverageType"`
+	Unit   string `xml:"Period>Unit"`
+	Number string `xml:"Period>Number"`
+}
+
+// ISSNs returns the ISSNs of all versions in the form 1234-567X.
+func (h Holding) ISSNs() []string {
+	var issns []string
+	for _, v := range h.Versions {
+		for _, id := range v.Identifiers {
+			if id.Type != "07" && !strings.EqualFold(id.Type, "ISSN") {
+				continue
+			}
+			if issn, ok := holdings.NormalizeISSN(id.Value); ok {
+				issns = append(issns, issn)
+			}
+		}
+	}
+	return issns
+}
+
+// Entries returns the coverage of a holding. Several fixed coverages in one
+// statement yield several entries, each with the moving wall of the
+// statement. Coverage without a date, e.g. a moving coverage only, is open.
+func (h Holding) Entries() ([]holdings.Entry, error) {
+	var entries []holdings.Entry
+	title := strings.TrimSpace(strings.Join(h.Title, " "))
+	for _, cov := range append(append([]Coverage{}, h.Coverage...), h.Online...) {
+		wall, err := cov.wall()
+		if err != nil {
+			return entries, err
+		}
+		fixed := cov.Fixed
+		if len(fixed) == 0 {
+			// moving coverage only
+			fixed = []FixedCoverage{{}}
+		}
+		for _, f := range fixed {
+			entry, err := f.entry()
+			if err != nil {
+				return entries, err
+			}
+			entry.Title = title
+			entry.Embargo, entry.EmbargoDisallowEarlier = wall.Embargo, wall.EmbargoDisallowEarlier
+			entry.Open = entry.Begin.Date == "" && entry.End.Date == ""
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// wall combines the moving coverages of a statement into a single moving
+// wall, since they all apply: of several embargoes the longest, of several
+// rolling periods the shortest.
+func (c Coverage) wall() (holdings.Entry, error) {
+	var wall holdings.Entry
+	for i, m := range c.Moving {
+		w, err := m.entry()
+		if err != nil {
+			return wall, err
+		}
+		switch {
+		case i == 0:
+			wall = w
+		case w.EmbargoDisallowEarlier != wall.EmbargoDisallowEarlier:
+			return wall, ErrConflictingWalls
+		case !w.EmbargoDisallowEarlier && w.Embargo < wall.Embargo:
+			wall = w
+		case w.EmbargoDisallowEarlier && w.Embargo > wall.Embargo:
+			wall = w
+		}
+	}
+	return wall, nil
+}
+
+// entry returns begin and end of a fixed coverage.
+func (f FixedCoverage) entry() (holdings.Entry, error) {
+	var entry holdings.Entry
+	first, last := f.From, f.To
+	if first == nil {
+		first = f.FirstIssue
+	}
+	if last == nil {
+		last = f.LastIssue
+	}
+	if first == nil && len(f.Releases) > 0 {
+		first = &f.Releases[0]
+		if last == nil {
+			last = &f.Releases[len(f.Releases)-1]
+		}
+	}
+	var err error
+	if first != nil {
+		if entry.Begin, err = first.Signature(); err != nil {
+			return entry, err
+		}
+	}
+	if last != nil {
+		if entry.End, err = last.Signature(); err != nil {
+			return entry, err
+		}
+	}
+	return entry, nil
+}
+
+// Signature returns volume, issue and date of a release. Levels with a
+// Unit of volume or issue are used as such, otherwise the first level is
+// the volume and the second the issue.
+func (r Release) Signature() (holdings.Signature, error) {
+	var s holdings.Signature
+	for i, level := range r.Enumeration.Levels {
+		unit := strings.ToLower(level.Unit)
+		number := strings.TrimSpace(level.Number)
+		switch {
+		case strings.HasPrefix(unit, "vol"):
+			s.Volume = number
+		case strings.HasPrefix(unit, "iss") || strings.HasPrefix(unit, "no") || strings.HasPrefix(unit, "num"):
+			s.Issue = number
+		case unit == "" && i == 0:
+			s.Volume = number
+		case unit == "" && i == 1:
+			s.Issue = number
+		}
+	}
+	date := r.Date
+	if date == "" {
+		date = r.PlainDate
+	}
+	if date = strings.TrimSpace(date); date != "" {
+		ms := datePattern.FindStringSubmatch(date)
+		if ms == nil {
+			return s, ErrInvalidDate
+		}
+		s.Date = ms[1]
+		if ms[2] != "" {
+			s.Date += "-" + ms[2]
+			if ms[3] != "" {
+				s.Date += "-" + ms[3]
+			}
+		}
+	}
+	return s, nil
+}
+
+// entry returns a moving wall as an entry without coverage.
+func (m MovingCoverage) entry() (holdings.Entry, error) {
+	var entry holdings.Entry
+	n, err := strconv.Atoi(strings.TrimSpace(m.Number))
+	if err != nil || n < 0 {
+		return entry, ErrInvalidPeriod
+	}
+	var unit time.Duration
+	switch strings.ToLower(strings.TrimSpace(m.Unit)) {
+	case "d", "day", "days":
+		unit = holdings.Day
+	case "m", "month", "months":
+		unit = holdings.Month
+	case "y", "year", "years":
+		unit = holdings.Year
+	default:
+		return entry, ErrInvalidPeriod
+	}
+	entry.Embargo = -time.Duration(n) * unit
+	entry.EmbargoDisallowEarlier = strings.TrimSpace(m.Type) == "02"
+	return entry, nil
+}
+
+// Reader reads ONIX for Serials Online Holdings messages. By default, errors
+// in single holdings are collected and returned as holdings.ParseError.
+type Reader struct {
+	r      io.Reader
+	Policy holdings.ErrorPolicy
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:      bufio.NewReader(r),
+		Policy: holdings.ErrorPolicy{Action: holdings.SkipAndCollect},
+	}
+}
+
+func (r *Reader) ReadAll() (holdings.Entries, error) {
+	entries := make(holdings.Entries)
+	sr := snippet.NewReader(r.r)
+	decoder := xml.NewDecoder(sr)
+
+	// collect errors, if the policy says so
+	perr := holdings.ParseError{}
+
+	for {
+		line, column := decoder.InputPos()
+		sr.Mark(decoder.InputOffset())
+
+		t, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		se, ok := t.(xml.StartElement)
+		if !ok || se.Name.Local != "OnlineSerialHoldings" {
+			continue
+		}
+		var h Holding
+		err = decoder.DecodeElement(&h, &se)
+		var parsed []holdings.Entry
+		if err == nil {
+			parsed, err = h.Entries()
+		}
+		if err == nil && len(h.ISSNs()) == 0 {
+			err = ErrMissingIdentifiers
+		}
+		if err != nil {
+			rerr := &holdings.RecordError{
+				Line:    line,
+				Column:  column,
+				Snippet: sr.String(),
+				Err:     err,
+			}
+			if issns := h.ISSNs(); len(issns) > 0 {
+				rerr.Record = issns[0]
+			}
+			if err := r.Policy.Handle(&perr, rerr); err != nil {
+				return entries, err
+			}
+			continue
+		}
+		for _, issn := range h.ISSNs() {
+			for _, entry := range parsed {
+				entries[issn] = append(entries[issn], entry)
+			}
+		}
+	}
+	if len(perr.Errors) > 0 {
+		return entries, perr
+	}
+	return entries, nil
+}
@@ -0,0 +1,147 @@
+package onixserials
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miku/holdings"
+)
+
+const doc = `<SerialsOnlineHoldings>
+<Header/>
+<OnlinePackage>
+<OnlineSerialHoldings>
+  <SerialWork><Title><TitleText>Journal of Things</TitleText></Title></SerialWork>
+  <SerialVersion>
+    <ProductIdentifier><ProductIDType>07</ProductIDType><IDValue>00062499</IDValue></ProductIdentifier>
+    <ProductForm>JD</ProductForm>
+  </SerialVersion>
+  <Coverage>
+    <FixedCoverage>
+      <From>
+        <Enumeration>
+          <Level1><Unit>Volume</Unit><Number>10</Number></Level1>
+          <Level2><Unit>Issue</Unit><Number>2</Number></Level2>
+        </Enumeration>
+        <NominalDate><Calendar><DateFormat>01</DateFormat><Date>200903</Date></Calendar></NominalDate>
+      </From>
+    </FixedCoverage>
+    <MovingCoverage>
+      <MovingCoverageType>01</MovingCoverageType>
+      <Period><Unit>Month</Unit><Number>6</Number></Period>
+    </MovingCoverage>
+  </Coverage>
+</OnlineSerialHoldings>
+<OnlineSerialHoldings>
+  <SerialVersion>
+    <ProductIdentifier><ProductIDType>07</ProductIDType><IDValue>1613-4141</IDValue></ProductIdentifier>
+  </SerialVersion>
+  <Coverage><MovingCoverage><Period><Unit>fortnight</Unit><Number>1</Number></Period></MovingCoverage></Coverage>
+</OnlineSerialHoldings>
+</OnlinePackage>
+</SerialsOnlineHoldings>`
+
+func TestReadAll(t *testing.T) {
+	entries, err := NewReader(strings.NewReader(doc)).ReadAll()
+	var perr holdings.ParseError
+	if !errors.As(err, &perr) || len(perr.Errors) != 1 || !errors.Is(err, ErrInvalidPeriod) {
+		t.Fatalf("ReadAll: got %v, want a single invalid period", err)
+	}
+	want := holdings.Entries{
+		"0006-2499": []holdings.License{holdings.Entry{
+			Begin:   holdings.Signature{Date: "2009-03", Volume: "10", Issue: "2"},
+			Embargo: -6 * holdings.Month,
+			Title:   "Journal of Things",
+		}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ReadAll: got %+v, want %+v", entries, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	doc := `<SerialsOnlineHoldings><OnlinePackage>
+<OnlineSerialHoldings>
+  <SerialVersion><ProductIdentifier><ProductIDType>07</ProductIDType><IDValue>0006-2499</IDValue></ProductIdentifier></SerialVersion>
+  <Coverage><MovingCoverage><MovingCoverageType>01</MovingCoverageType><Period><Unit>Year</Unit><Number>1</Number></Period></MovingCoverage></Coverage>
+</OnlineSerialHoldings>
+</OnlinePackage></SerialsOnlineHoldings>`
+	entries, err := NewReader(strings.NewReader(doc)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases = []struct {
+		date string
+		ok   bool
+	}{
+		{"1850", true},
+		{time.Now().Format("2006"), false},
+	}
+	for _, c := range cases {
+		r := holdings.Record{Identifiers: []string{"0006-2499"}, Signature: holdings.Signature{Date: c.date}}
+		if d := holdings.Check(entries, r); d.OK != c.ok {
+			t.Errorf("Check(%s): got %v (%v), want %v", c.date, d.OK, d.Err, c.ok)
+		}
+	}
+}
+
+func TestCoverageWall(t *testing.T) {
+	moving := func(typ, number string) MovingCoverage {
+		return MovingCoverage{Type: typ, Unit: "year", Number: number}
+	}
+	var cases = []struct {
+		moving []MovingCoverage
+		want   holdings.Entry
+		err    error
+	}{
+		{nil, holdings.Entry{}, nil},
+		{[]MovingCoverage{moving("01", "1"), moving("01", "2")}, holdings.Entry{Embargo: -2 * holdings.Year}, nil},
+		{[]MovingCoverage{moving("02", "5"), moving("02", "3")},
+			holdings.Entry{Embargo: -3 * holdings.Year, EmbargoDisallowEarlier: true}, nil},
+		{[]MovingCoverage{moving("01", "1"), moving("02", "5")}, holdings.Entry{}, ErrConflictingWalls},
+	}
+	for _, c := range cases {
+		got, err := Coverage{Moving: c.moving}.wall()
+		if err != c.err || (err == nil && got != c.want) {
+			t.Errorf("wall(%+v): got %+v, %v, want %+v, %v", c.moving, got, err, c.want, c.err)
+		}
+	}
+}
+
+func TestReleaseSignature(t *testing.T) {
+	var cases = []struct {
+		r    Release
+		want holdings.Signature
+		err  error
+	}{
+		{Release{PlainDate: "2001"}, holdings.Signature{Date: "2001"}, nil},
+		{Release{Date: "20010215"}, holdings.Signature{Date: "2001-02-15"}, nil},
+		{Release{Enumeration: Enumeration{Levels: []Level{{Number: "3"}, {Number: "4"}}}},
+			holdings.Signature{Volume: "3", Issue: "4"}, nil},
+		{Release{Date: "spring"}, holdings.Signature{}, ErrInvalidDate},
+	}
+	for _, c := range cases {
+		got, err := c.r.Signature()
+		if err != c.err || (err == nil && got != c.want) {
+			t.Errorf("Signature(%+v): got %+v, %v, want %+v, %v", c.r, got, err, c.want, c.err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	report, err := NewReader(strings.NewReader(doc)).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 2 || len(report.Problems) != 1 {
+		t.Fatalf("Validate: got %d records and %+v", report.Records, report.Problems)
+	}
+	p := report.Problems[0]
+	want := "/SerialsOnlineHoldings/OnlineSerialHoldings[2]/Coverage[1]/MovingCoverage[1]/Period"
+	if p.Rule != RulePeriod || p.Path != want {
+		t.Errorf("Validate: got %s %s, want %s %s", p.Rule, p.Path, RulePeriod, want)
+	}
+}
@@ -0,0 +1,124 @@
+package onixserials
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/miku/holdings"
+)
+
+// Rule names, see Rules for a description.
+const (
+	RuleXML        = "xml"
+	RuleDecode     = "decode"
+	RuleIdentifier = "identifier"
+	RuleISSN       = "issn"
+	RuleDate       = "date"
+	RulePeriod     = "period"
+	RuleWalls      = "walls"
+	RuleDateOrder  = "date-order"
+)
+
+// Rules is the catalog of checks performed by Validate.
+var Rules = holdings.Catalog{
+	{Name: RuleXML, Severity: holdings.Error, Description: "document must be well-formed XML"},
+	{Name: RuleDecode, Severity: holdings.Error, Description: "holding must decode into the expected structure"},
+	{Name: RuleIdentifier, Severity: holdings.Error, Description: "holding must have a serial version with an ISSN"},
+	{Name: RuleISSN, Severity: holdings.Error, Description: "ISSN must have a valid check digit"},
+	{Name: RuleDate, Severity: holdings.Error, Description: "NominalDate must be YYYY, YYYYMM or YYYYMMDD"},
+	{Name: RulePeriod, Severity: holdings.Error, Description: "moving coverage must have a Period with Unit and Number"},
+	{Name: RuleWalls, Severity: holdings.Error, Description: "moving coverages of a statement must not mix embargo and rolling period"},
+	{Name: RuleDateOrder, Severity: holdings.Error, Description: "coverage must not begin after it ends"},
+}
+
+// Validate reads the remaining input and checks every holding. Problems are
+// located by the element path and the byte offset of the holding. The
+// returned error is only non-nil for I/O errors.
+func (r *Reader) Validate() (holdings.Report, error) {
+	v := holdings.Collector{Rules: Rules}
+	decoder := xml.NewDecoder(r.r)
+
+	var root string
+	var n int
+
+	for {
+		offset := decoder.InputOffset()
+		line, column := decoder.InputPos()
+		t, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*xml.SyntaxError); ok {
+				line, column := decoder.InputPos()
+				v.At = holdings.Problem{Line: line, Column: column, Offset: decoder.InputOffset()}
+				v.Add(RuleXML, "", "", err.Error())
+				return v.Report, nil
+			}
+			return v.Report, err
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root == "" {
+			root = "/" + se.Name.Local
+		}
+		if se.Name.Local != "OnlineSerialHoldings" {
+			continue
+		}
+		n++
+		v.Report.Records++
+
+		path := fmt.Sprintf("%s/OnlineSerialHoldings[%d]", root, n)
+		v.At = holdings.Problem{Line: line, Column: column, Offset: offset, Path: path}
+
+		var h Holding
+		if err := decoder.DecodeElement(&h, &se); err != nil {
+			v.Add(RuleDecode, "", "", err.Error())
+			continue
+		}
+		if len(h.ISSNs()) == 0 {
+			v.Add(RuleIdentifier, "", "", "no ISSN")
+		}
+		for i, version := range h.Versions {
+			for j, id := range version.Identifiers {
+				if (id.Type == "07" || id.Type == "ISSN") && !holdings.ValidISSN(id.Value) {
+					v.Add(RuleISSN, fmt.Sprintf("/SerialVersion[%d]/ProductIdentifier[%d]/IDValue", i+1, j+1),
+						id.Value, "invalid ISSN")
+				}
+			}
+		}
+		for i, cov := range append(append([]Coverage{}, h.Coverage...), h.Online...) {
+			p := fmt.Sprintf("/Coverage[%d]", i+1)
+			if i >= len(h.Coverage) {
+				p = fmt.Sprintf("/OnlineHoldings/Coverage[%d]", i-len(h.Coverage)+1)
+			}
+			var invalid bool
+			for j, m := range cov.Moving {
+				if _, err := m.entry(); err != nil {
+					v.Add(RulePeriod, fmt.Sprintf("%s/MovingCoverage[%d]/Period", p, j+1),
+						m.Number+" "+m.Unit, err.Error())
+					invalid = true
+				}
+			}
+			if _, err := cov.wall(); !invalid && err != nil {
+				v.Add(RuleWalls, p, "", err.Error())
+			}
+			for j, f := range cov.Fixed {
+				fp := fmt.Sprintf("%s/FixedCoverage[%d]", p, j+1)
+				e, err := f.entry()
+				if err != nil {
+					v.Add(RuleDate, fp, "", err.Error())
+					continue
+				}
+				if e.Begin.Date != "" && e.End.Date != "" && e.Begin.Date > e.End.Date {
+					v.Add(RuleDateOrder, fp, e.End.Date, "end before begin "+e.Begin.Date)
+				}
+			}
+		}
+	}
+	return v.Report, nil
+}
@@ -0,0 +1,152 @@
+// Package openurl implements a minimal link resolver. It parses OpenURL 1.0
+// key/encoded-value context objects, checks the referent against holdings
+// and answers with JSON or redirects to the title URL of the license, that
+// grants access.
+package openurl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/links"
+)
+
+// ContextObject is the referent of an OpenURL.
+type ContextObject struct {
+	ISSN      []string           `json:"issn,omitempty"`
+	EISSN     []string           `json:"eissn,omitempty"`
+	ISBN      []string           `json:"isbn,omitempty"`
+	DOI       string             `json:"doi,omitempty"`
+	Signature holdings.Signature `json:"signature"`
+	StartPage string             `json:"spage,omitempty"`
+}
+
+// Parse reads a context object from KEV values. Besides the OpenURL 1.0
+// keys rft.issn, rft.eissn, rft.isbn, rft.date, rft.volume, rft.issue,
+// rft.edition and rft.spage, the OpenURL 0.1 keys without prefix are
+// accepted. A DOI is taken from rft_id=info:doi/... or rft.doi.
+func Parse(v url.Values) ContextObject {
+	get := func(key string) []string {
+		var values []string
+		for _, k := range []string{"rft." + key, key} {
+			for _, s := range v[k] {
+				if s = strings.TrimSpace(s); s != "" {
+					values = append(values, s)
+				}
+			}
+		}
+		return values
+	}
+	first := func(key string) string {
+		if vs := get(key); len(vs) > 0 {
+			return vs[0]
+		}
+		return ""
+	}
+	c := ContextObject{
+		ISSN:  normalizeISSNs(get("issn")),
+		EISSN: normalizeISSNs(get("eissn")),
+		ISBN:  get("isbn"),
+		DOI:   first("doi"),
+		Signature: holdings.Signature{
+			Date:    first("date"),
+			Volume:  first("volume"),
+			Issue:   first("issue"),
+			Edition: first("edition"),
+		},
+		StartPage: first("spage"),
+	}
+	for _, id := range v["rft_id"] {
+		if strings.HasPrefix(id, "info:doi/") && c.DOI == "" {
+			c.DOI = strings.TrimPrefix(id, "info:doi/")
+		}
+	}
+	return c
+}
+
+// normalizeISSNs writes ISSNs as 1234-567X, as used by the holdings files.
+func normalizeISSNs(issns []string) []string {
+	var result []string
+	for _, s := range issns {
+		if issn, ok := holdings.NormalizeISSN(s); ok {
+			s = issn
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// Identifiers returns ISSNs, online ISSNs and ISBNs in this order.
+func (c ContextObject) Identifiers() []string {
+	var ids []string
+	ids = append(ids, c.ISSN...)
+	ids = append(ids, c.EISSN...)
+	ids = append(ids, c.ISBN...)
+	return ids
+}
+
+// Record returns the referent as a record to check.
+func (c ContextObject) Record() holdings.Record {
+	return holdings.Record{Identifiers: c.Identifiers(), Signature: c.Signature}
+}
+
+// Response is the JSON answer of the handler.
+type Response struct {
+	Referent   ContextObject       `json:"referent"`
+	OK         bool                `json:"ok"`
+	Identifier string              `json:"identifier,omitempty"`
+	URL        string              `json:"url,omitempty"`
+	Level      links.Level         `json:"level,omitempty"`
+	Access     holdings.AccessType `json:"access,omitempty"`
+	Reason     string              `json:"reason,omitempty"`
+}
+
+// Handler resolves OpenURL requests against holdings. The URL of a granted
+// request is the best link built by Templates, which falls back to the title
+// URL of the license. If Redirect is set, a request with a URL is redirected
+// there; a request with format=json always gets JSON.
+type Handler struct {
+	Holdings  holdings.Holdings
+	Templates links.Templates
+	Redirect  bool
+}
+
+// Resolve checks a context object against the holdings.
+func (h Handler) Resolve(c ContextObject) Response {
+	resp := Response{Referent: c}
+	d := holdings.Check(h.Holdings, c.Record())
+	resp.OK, resp.Identifier, resp.Access = d.OK, d.Identifier, d.Access
+	if !d.OK {
+		resp.Reason = d.Err.Error()
+		return resp
+	}
+	a := links.Article{DOI: c.DOI, Signature: c.Signature, StartPage: c.StartPage}
+	if link, ok := h.Templates.Decision(d, a); ok {
+		resp.URL, resp.Level = link.URL, link.Level
+	}
+	return resp
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c := Parse(r.Form)
+	if len(c.Identifiers()) == 0 {
+		http.Error(w, "missing rft.issn, rft.eissn or rft.isbn", http.StatusBadRequest)
+		return
+	}
+	resp := h.Resolve(c)
+	if h.Redirect && resp.URL != "" && r.Form.Get("format") != "json" {
+		http.Redirect(w, r, resp.URL, http.StatusFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,81 @@
+package openurl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/miku/holdings"
+	"github.com/miku/holdings/links"
+)
+
+var entries = holdings.Entries{
+	"0006-2499": []holdings.License{
+		holdings.Entry{Begin: holdings.Signature{Date: "1990"}, End: holdings.Signature{Date: "1994"}},
+		holdings.Entry{Begin: holdings.Signature{Date: "1995"}, URL: "http://example.com/journal"},
+	},
+}
+
+func TestParse(t *testing.T) {
+	v, _ := url.ParseQuery("url_ver=Z39.88-2004&rft.issn=00062499&rft.eissn=1613-414x&rft.date=2001-05&volume=10&rft.issue=2&rft.spage=7&rft_id=info:doi/10.1000/x")
+	got := Parse(v)
+	want := ContextObject{
+		ISSN:      []string{"0006-2499"},
+		EISSN:     []string{"1613-414X"},
+		DOI:       "10.1000/x",
+		Signature: holdings.Signature{Date: "2001-05", Volume: "10", Issue: "2"},
+		StartPage: "7",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse: got %+v, want %+v", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var cases = []struct {
+		query    string
+		redirect bool
+		status   int
+		location string
+		resp     Response
+	}{
+		{"rft.issn=0006-2499&rft.date=2001", true, http.StatusFound, "http://example.com/journal", Response{}},
+		{"rft.issn=0006-2499&rft.date=2001&format=json", true, http.StatusOK, "", Response{
+			Referent:   ContextObject{ISSN: []string{"0006-2499"}, Signature: holdings.Signature{Date: "2001"}},
+			OK:         true,
+			Identifier: "0006-2499",
+			URL:        "http://example.com/journal",
+			Level:      links.JournalLevel,
+		}},
+		{"rft.issn=0006-2499&rft.date=1980", false, http.StatusOK, "", Response{
+			Referent: ContextObject{ISSN: []string{"0006-2499"}, Signature: holdings.Signature{Date: "1980"}},
+			Reason:   holdings.ErrBeforeCoverageInterval.Error(),
+		}},
+		{"rft.date=1980", false, http.StatusBadRequest, "", Response{}},
+	}
+	for _, c := range cases {
+		h := Handler{Holdings: entries, Redirect: c.redirect}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/?"+c.query, nil))
+		if w.Code != c.status {
+			t.Errorf("%s: got status %d, want %d", c.query, w.Code, c.status)
+			continue
+		}
+		if loc := w.Header().Get("Location"); loc != c.location {
+			t.Errorf("%s: got location %q, want %q", c.query, loc, c.location)
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+		var resp Response
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(resp, c.resp) {
+			t.Errorf("%s: got %+v, want %+v", c.query, resp, c.resp)
+		}
+	}
+}
@@ -3,21 +3,28 @@ package ovid
 import (
 	"bufio"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
 	"regexp"
 	"strconv"
 	"time"
 
 	"github.com/miku/holdings"
+	"github.com/miku/holdings/internal/snippet"
 )
 
 // delayPattern is how moving walls are expressed in OVID.
 var delayPattern = regexp.MustCompile(`^([-+]\d+)(M|Y)$`)
 
+// ErrConflictingDelays is returned for entitlements with both a begin and an
+// end delay, which a single entry cannot express.
+var ErrConflictingDelays = errors.New("begin and end delay")
+
 var (
-	Day   = 24 * time.Hour
-	Month = 30 * Day
-	Year  = 12 * Month
+	Day   = holdings.Day
+	Month = holdings.Month
+	Year  = holdings.Year
 )
 
 // Holding contains a single holding.
@@ -45,12 +52,21 @@ type Entitlement struct {
 	ToDelay    string `xml:"end>delay" json:"to-delay"`
 }
 
+// Reader reads OVID XML. By default, errors in single holdings are
+// collected and returned as holdings.ParseError; this includes entitlements
+// with both a begin and an end delay, which are skipped. If Statuses is not
+// empty, only entitlements with one of the given statuses are read.
 type Reader struct {
-	r io.Reader
+	r        io.Reader
+	Policy   holdings.ErrorPolicy
+	Statuses []string
 }
 
 func NewReader(r io.Reader) *Reader {
-	return &Reader{r: bufio.NewReader(r)}
+	return &Reader{
+		r:      bufio.NewReader(r),
+		Policy: holdings.ErrorPolicy{Action: holdings.SkipAndCollect},
+	}
 }
 
 // parseEmbargo parses delay strings like '-1M' or '-3Y' into a time.Duration.
@@ -79,14 +95,17 @@ func parseEmbargo(s string) time.Duration {
 
 func (r Reader) ReadAll() (holdings.Entries, error) {
 	entries := make(holdings.Entries)
-	decoder := xml.NewDecoder(r.r)
+	sr := snippet.NewReader(r.r)
+	decoder := xml.NewDecoder(sr)
 
-	// collect errors, let caller decide policy
+	// collect errors, if the policy says so
 	perr := holdings.ParseError{}
 
 	var tag string
 
 	for {
+		line, column := decoder.InputPos()
+		sr.Mark(decoder.InputOffset())
 
 		t, err := decoder.Token()
 		if err == io.EOF {
@@ -105,11 +124,23 @@ func (r Reader) ReadAll() (holdings.Entries, error) {
 			if tag == "holding" {
 				var item Holding
 				if err := decoder.DecodeElement(&item, &se); err != nil {
-					perr.Errors = append(perr.Errors, err)
+					rerr := &holdings.RecordError{
+						Line:    line,
+						Column:  column,
+						Record:  attr(se, "ezb_id"),
+						Snippet: sr.String(),
+						Err:     err,
+					}
+					if err := r.Policy.Handle(&perr, rerr); err != nil {
+						return entries, err
+					}
 					continue
 				}
 
 				for _, ent := range item.Entitlements {
+					if !r.accept(ent.Status) {
+						continue
+					}
 					entry := holdings.Entry{
 						Begin: holdings.Signature{
 							Date:   ent.FromYear,
@@ -121,7 +152,34 @@ func (r Reader) ReadAll() (holdings.Entries, error) {
 							Volume: ent.ToVolume,
 							Issue:  ent.ToIssue,
 						},
-						Embargo: parseEmbargo(ent.FromDelay),
+						Status: ent.Status,
+						Title:  item.Title,
+						URL:    ent.URL,
+					}
+					// A begin delay is a classic embargo, the most recent
+					// content is not available. An end delay is a rolling
+					// end, older content is not available. Both together
+					// would need two walls on one entry; licenses are
+					// alternatives, so two entries would grant everything.
+					if ent.FromDelay != "" && ent.ToDelay != "" {
+						rerr := &holdings.RecordError{
+							Line:    line,
+							Column:  column,
+							Record:  attr(se, "ezb_id"),
+							Snippet: sr.String(),
+							Err:     fmt.Errorf("%w: %s, %s", ErrConflictingDelays, ent.FromDelay, ent.ToDelay),
+						}
+						if err := r.Policy.Handle(&perr, rerr); err != nil {
+							return entries, err
+						}
+						continue
+					}
+					switch {
+					case ent.FromDelay != "":
+						entry.Embargo = parseEmbargo(ent.FromDelay)
+					case ent.ToDelay != "":
+						entry.Embargo = parseEmbargo(ent.ToDelay)
+						entry.EmbargoDisallowEarlier = true
 					}
 					for _, issn := range append(item.EISSN, item.PISSN...) {
 						entries[issn] = append(entries[issn], entry)
@@ -135,3 +193,27 @@ func (r Reader) ReadAll() (holdings.Entries, error) {
 	}
 	return entries, nil
 }
+
+// accept returns true, if an entitlement with the given status should be
+// read.
+func (r Reader) accept(status string) bool {
+	if len(r.Statuses) == 0 {
+		return true
+	}
+	for _, s := range r.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// attr returns the value of an attribute or the empty string.
+func attr(se xml.StartElement, name string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
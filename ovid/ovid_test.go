@@ -0,0 +1,122 @@
+package ovid
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miku/holdings"
+)
+
+func TestReadAllParseError(t *testing.T) {
+	doc := `<holdings>
+<holding ezb_id="1"><EZBIssns><p-issn>0006-2499</p-issn></EZBIssns></holding>
+<holding ezb_id="x"></holding>
+<holding ezb_id="y"></holding>
+<holding ezb_id="z"></holding>
+</holdings>`
+
+	var cases = []struct {
+		maxErrors int
+		count     int
+		tooMany   bool
+	}{
+		{0, 3, false},
+		{2, 3, true},
+	}
+	for _, c := range cases {
+		r := NewReader(strings.NewReader(doc))
+		r.Policy.MaxErrors = c.maxErrors
+		_, err := r.ReadAll()
+
+		var perr holdings.ParseError
+		if !errors.As(err, &perr) {
+			t.Fatalf("ReadAll got %v, want holdings.ParseError", err)
+		}
+		if len(perr.Errors) != c.count {
+			t.Errorf("got %d errors, want %d", len(perr.Errors), c.count)
+		}
+		if errors.Is(err, holdings.ErrTooManyErrors) != c.tooMany {
+			t.Errorf("got %v, want too many errors %v", err, c.tooMany)
+		}
+		var rerr *holdings.RecordError
+		if !errors.As(err, &rerr) {
+			t.Fatalf("ReadAll got %v, want *holdings.RecordError", err)
+		}
+		if rerr.Line != 3 || rerr.Column != 1 || rerr.Record != "x" || rerr.Snippet != `<holding ezb_id="x">` {
+			t.Errorf("got line %d, column %d, record %q, snippet %q",
+				rerr.Line, rerr.Column, rerr.Record, rerr.Snippet)
+		}
+	}
+}
+
+func TestReadAllDelayAndStatus(t *testing.T) {
+	doc := `<holdings>
+<holding ezb_id="1">
+  <title>A</title>
+  <EZBIssns><p-issn>0006-2499</p-issn></EZBIssns>
+  <entitlements>
+    <entitlement status="subscribed"><begin><year>2000</year><delay>-1Y</delay></begin></entitlement>
+    <entitlement status="free"><begin><year>1990</year></begin><end><delay>-2Y</delay></end></entitlement>
+    <entitlement status="cancelled"><begin><year>1980</year></begin></entitlement>
+  </entitlements>
+</holding>
+</holdings>`
+
+	r := NewReader(strings.NewReader(doc))
+	r.Statuses = []string{"subscribed", "free"}
+	entries, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []holdings.License{
+		holdings.Entry{
+			Begin:   holdings.Signature{Date: "2000"},
+			Embargo: -1 * Year,
+			Status:  "subscribed",
+			Title:   "A",
+		},
+		holdings.Entry{
+			Begin:                  holdings.Signature{Date: "1990"},
+			Embargo:                -2 * Year,
+			EmbargoDisallowEarlier: true,
+			Status:                 "free",
+			Title:                  "A",
+		},
+	}
+	if !reflect.DeepEqual(entries["0006-2499"], want) {
+		t.Errorf("ReadAll got %+v, want %+v", entries["0006-2499"], want)
+	}
+
+	license := entries["0006-2499"][1]
+	if err := license.TimeRestricted(time.Now().Add(-3 * Year)); err != holdings.ErrMovingWall {
+		t.Errorf("TimeRestricted got %v, want %v", err, holdings.ErrMovingWall)
+	}
+	if err := license.TimeRestricted(time.Now().Add(-1 * Year)); err != nil {
+		t.Errorf("TimeRestricted got %v, want nil", err)
+	}
+}
+
+func TestReadAllConflictingDelays(t *testing.T) {
+	doc := `<holdings>
+<holding ezb_id="1">
+  <EZBIssns><p-issn>0006-2499</p-issn></EZBIssns>
+  <entitlements>
+    <entitlement><begin><year>1990</year><delay>-1Y</delay></begin><end><delay>-5Y</delay></end></entitlement>
+    <entitlement><begin><year>2000</year></begin></entitlement>
+  </entitlements>
+</holding>
+</holdings>`
+
+	entries, err := NewReader(strings.NewReader(doc)).ReadAll()
+	var rerr *holdings.RecordError
+	if !errors.As(err, &rerr) || !errors.Is(err, ErrConflictingDelays) || rerr.Record != "1" {
+		t.Fatalf("ReadAll: got %v, want %v for record 1", err, ErrConflictingDelays)
+	}
+	want := []holdings.License{holdings.Entry{Begin: holdings.Signature{Date: "2000"}}}
+	if !reflect.DeepEqual(entries["0006-2499"], want) {
+		t.Errorf("ReadAll got %+v, want %+v", entries["0006-2499"], want)
+	}
+}
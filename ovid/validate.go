@@ -0,0 +1,112 @@
+package ovid
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/miku/holdings"
+)
+
+// Rule names, see Rules for a description.
+const (
+	RuleXML       = "xml"
+	RuleDecode    = "decode"
+	RuleISSN      = "issn"
+	RuleDelay     = "delay"
+	RuleDateOrder = "date-order"
+	RuleDelays    = "delays"
+)
+
+// Rules is the catalog of checks performed by Validate.
+var Rules = holdings.Catalog{
+	{Name: RuleXML, Severity: holdings.Error, Description: "document must be well-formed XML"},
+	{Name: RuleDecode, Severity: holdings.Error, Description: "holding must decode into the expected structure"},
+	{Name: RuleISSN, Severity: holdings.Error, Description: "p-issn and e-issn must be ISSN with a valid check digit"},
+	{Name: RuleDelay, Severity: holdings.Error, Description: "delay must look like -1Y or -6M"},
+	{Name: RuleDateOrder, Severity: holdings.Error, Description: "entitlement must not begin after it ends"},
+	{Name: RuleDelays, Severity: holdings.Error, Description: "only one of begin and end delay can be set, the entitlement is skipped otherwise"},
+}
+
+// Validate reads the remaining input and checks every holding. Problems are
+// located by the element path and the byte offset of the holding. The
+// returned error is only non-nil for I/O errors.
+func (r Reader) Validate() (holdings.Report, error) {
+	v := holdings.Collector{Rules: Rules}
+	decoder := xml.NewDecoder(r.r)
+
+	var root string
+	var n int
+
+	for {
+		offset := decoder.InputOffset()
+		line, column := decoder.InputPos()
+		t, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*xml.SyntaxError); ok {
+				line, column := decoder.InputPos()
+				v.At = holdings.Problem{Line: line, Column: column, Offset: decoder.InputOffset()}
+				v.Add(RuleXML, "", "", err.Error())
+				return v.Report, nil
+			}
+			return v.Report, err
+		}
+
+		se, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root == "" {
+			root = "/" + se.Name.Local
+		}
+		if se.Name.Local != "holding" {
+			continue
+		}
+		n++
+		v.Report.Records++
+
+		path := fmt.Sprintf("%s/holding[%d]", root, n)
+		v.At = holdings.Problem{Line: line, Column: column, Offset: offset, Path: path}
+
+		var item Holding
+		if err := decoder.DecodeElement(&item, &se); err != nil {
+			v.Add(RuleDecode, "", "", err.Error())
+			continue
+		}
+		for i, issn := range item.PISSN {
+			if !holdings.ValidISSN(issn) {
+				v.Add(RuleISSN, fmt.Sprintf("/EZBIssns/p-issn[%d]", i+1), issn, "invalid ISSN")
+			}
+		}
+		for i, issn := range item.EISSN {
+			if !holdings.ValidISSN(issn) {
+				v.Add(RuleISSN, fmt.Sprintf("/EZBIssns/e-issn[%d]", i+1), issn, "invalid ISSN")
+			}
+		}
+		for i, ent := range item.Entitlements {
+			p := fmt.Sprintf("/entitlements/entitlement[%d]", i+1)
+			if ent.FromDelay != "" && !delayPattern.MatchString(ent.FromDelay) {
+				v.Add(RuleDelay, p+"/begin/delay", ent.FromDelay, "invalid delay")
+			}
+			if ent.ToDelay != "" && !delayPattern.MatchString(ent.ToDelay) {
+				v.Add(RuleDelay, p+"/end/delay", ent.ToDelay, "invalid delay")
+			}
+			if ent.FromDelay != "" && ent.ToDelay != "" {
+				v.Add(RuleDelays, p+"/end/delay", ent.ToDelay, "begin and end delay")
+			}
+			if ent.FromYear != "" && ent.ToYear != "" && ent.FromYear > ent.ToYear {
+				v.Add(RuleDateOrder, p+"/end/year", ent.ToYear, "end before begin "+ent.FromYear)
+			}
+			if ent.FromVolume != "" && ent.ToVolume != "" {
+				from, to := holdings.Signature{Volume: ent.FromVolume}, holdings.Signature{Volume: ent.ToVolume}
+				if from.VolumeInt() > to.VolumeInt() {
+					v.Add(RuleDateOrder, p+"/end/volume", ent.ToVolume, "end before begin "+ent.FromVolume)
+				}
+			}
+		}
+	}
+	return v.Report, nil
+}
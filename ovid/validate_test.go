@@ -0,0 +1,49 @@
+package ovid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	doc := `<holdings>
+<holding ezb_id="1">
+  <title>A</title>
+  <EZBIssns><p-issn>0006-2499</p-issn><e-issn>0006-2490</e-issn></EZBIssns>
+  <entitlements>
+    <entitlement status="subscribed"><begin><year>2000</year><delay>-1Y</delay></begin><end><year>2010</year></end></entitlement>
+    <entitlement status="subscribed"><begin><year>2012</year><delay>1 year</delay></begin><end><year>2011</year></end></entitlement>
+  </entitlements>
+</holding>
+<holding ezb_id="x"></holding>
+</holdings>`
+
+	report, err := NewReader(strings.NewReader(doc)).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 2 {
+		t.Errorf("got %d records, want 2", report.Records)
+	}
+	var want = []struct {
+		rule string
+		path string
+	}{
+		{RuleISSN, "/holdings/holding[1]/EZBIssns/e-issn[1]"},
+		{RuleDelay, "/holdings/holding[1]/entitlements/entitlement[2]/begin/delay"},
+		{RuleDateOrder, "/holdings/holding[1]/entitlements/entitlement[2]/end/year"},
+		{RuleDecode, "/holdings/holding[2]"},
+	}
+	if len(report.Problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %+v", len(report.Problems), len(want), report.Problems)
+	}
+	for i, w := range want {
+		p := report.Problems[i]
+		if p.Rule != w.rule || p.Path != w.path {
+			t.Errorf("got %s at %s, want %s at %s", p.Rule, p.Path, w.rule, w.path)
+		}
+	}
+	if p := report.Problems[0]; p.Offset != 11 || p.Line != 2 {
+		t.Errorf("got offset %d, line %d, want 11, 2", p.Offset, p.Line)
+	}
+}
@@ -0,0 +1,48 @@
+package holdings
+
+// ErrorAction tells a reader, what to do about an error in a record.
+type ErrorAction int
+
+const (
+	// FailFast stops reading and returns the error.
+	FailFast ErrorAction = iota
+	// SkipAndCollect skips the record and reports the error at the end as
+	// part of a ParseError.
+	SkipAndCollect
+	// SkipSilently skips the record and forgets about the error.
+	SkipSilently
+)
+
+// ErrorPolicy is accepted by all readers and decides how errors in single
+// records are handled. If Hook is set, it is called for every error and its
+// result overrides Action, which makes it a good place for logging and
+// counting. Reading stops after MaxErrors collected errors, zero means no
+// limit.
+type ErrorPolicy struct {
+	Action    ErrorAction
+	Hook      func(*RecordError) ErrorAction
+	MaxErrors int
+}
+
+// Handle applies the policy to an error. Collected errors are added to
+// perr. A non-nil return value means, reading should stop and the value
+// should be returned to the caller.
+func (p ErrorPolicy) Handle(perr *ParseError, err *RecordError) error {
+	action := p.Action
+	if p.Hook != nil {
+		action = p.Hook(err)
+	}
+	switch action {
+	case SkipSilently:
+		return nil
+	case SkipAndCollect:
+		perr.Errors = append(perr.Errors, err)
+		if p.MaxErrors > 0 && len(perr.Errors) >= p.MaxErrors {
+			perr.Errors = append(perr.Errors, ErrTooManyErrors)
+			return *perr
+		}
+		return nil
+	default:
+		return err
+	}
+}
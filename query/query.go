@@ -0,0 +1,287 @@
+// Package query turns holdings into filter queries for a search index, so
+// results can be restricted to licensed content at query time. Coverage
+// becomes range queries over publication year. Moving walls become a range
+// over a date field or, if there is none, a conservative range over years.
+// Identifiers with the same coverage are grouped into a single clause.
+//
+// Filters follow Entry.Covers: entries without dates grant nothing, so they
+// yield no clause. Volumes and issues are not part of the filter, since
+// index fields for them are usually strings, where 9 sorts after 10. Within
+// the first and last year of a coverage, the filter may therefore let
+// through more than Entry.Covers. Books are matched by ISBN alone.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miku/holdings"
+)
+
+// Fields names the index fields. Year holds the publication year as a
+// number. Date is optional and holds the publication date as a date type.
+type Fields struct {
+	ISSN string
+	ISBN string
+	Year string
+	Date string
+}
+
+// DefaultFields follow the finc Solr schema.
+var DefaultFields = Fields{
+	ISSN: "issn",
+	ISBN: "isbn",
+	Year: "publishDateSort",
+}
+
+// Range is a range over a field. An empty From or To is open.
+type Range struct {
+	Field string
+	From  string
+	To    string
+}
+
+// Clause is a conjunction of ranges. An empty clause matches everything.
+type Clause []Range
+
+// Group is a set of identifiers, that share their coverage. A record
+// matches, if it carries one of the identifiers and matches any clause. The
+// identifiers of books are ISBNs.
+type Group struct {
+	IDs     []string
+	Clauses []Clause
+	ISBN    bool
+}
+
+// Builder builds filter groups. Moving walls are computed relative to Now.
+type Builder struct {
+	Fields Fields
+	Now    time.Time
+}
+
+// NewBuilder returns a builder with default fields and the current time.
+func NewBuilder() Builder {
+	return Builder{Fields: DefaultFields, Now: time.Now()}
+}
+
+// Groups returns the filter groups for all identifiers, sorted by their
+// first identifier. Identifiers without any usable coverage are left out.
+func (b Builder) Groups(entries holdings.Entries) []Group {
+	byKey := make(map[string]*Group)
+	for id, licenses := range entries {
+		var clauses []Clause
+		for _, e := range holdings.Normalize(licenses) {
+			if c, ok := b.Clause(e); ok {
+				clauses = append(clauses, c)
+			}
+		}
+		var isbn bool
+		for _, l := range licenses {
+			if _, ok := l.(holdings.Book); ok {
+				clauses, isbn = []Clause{nil}, true
+			}
+		}
+		if len(clauses) == 0 {
+			continue
+		}
+		key := fmt.Sprintf("%v|%s", isbn, key(clauses))
+		g, ok := byKey[key]
+		if !ok {
+			g = &Group{Clauses: clauses, ISBN: isbn}
+			byKey[key] = g
+		}
+		g.IDs = append(g.IDs, id)
+	}
+	var groups []Group
+	for _, g := range byKey {
+		sort.Strings(g.IDs)
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].IDs[0] < groups[j].IDs[0] })
+	return groups
+}
+
+// key identifies the coverage of a list of clauses.
+func key(clauses []Clause) string {
+	var parts []string
+	for _, c := range clauses {
+		parts = append(parts, fmt.Sprint(c))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "|")
+}
+
+// Clause returns the ranges for an entry and reports, whether the entry can
+// match anything at all. Like Entry.Covers, an entry without dates matches
+// nothing.
+func (b Builder) Clause(e holdings.Entry) (Clause, bool) {
+	if e.Begin.Date == "" && e.End.Date == "" {
+		return nil, false
+	}
+	var c Clause
+	var from, to string
+	if len(e.Begin.Date) >= 4 {
+		from = e.Begin.Date[:4]
+	}
+	if len(e.End.Date) >= 4 {
+		to = e.End.Date[:4]
+	}
+	if e.Embargo != 0 {
+		cutoff := b.Now.Add(e.Embargo)
+		if b.Fields.Date != "" {
+			r := Range{Field: b.Fields.Date}
+			if e.EmbargoDisallowEarlier {
+				r.From = cutoff.UTC().Format("2006-01-02T15:04:05Z")
+			} else {
+				r.To = cutoff.UTC().Format("2006-01-02T15:04:05Z")
+			}
+			c = append(c, r)
+		} else {
+			// only whole years on the right side of the wall
+			if e.EmbargoDisallowEarlier {
+				from = maxYear(from, strconv.Itoa(cutoff.Year()+1))
+			} else {
+				to = minYear(to, strconv.Itoa(cutoff.Year()-1))
+			}
+		}
+	}
+	if from != "" && to != "" && from > to {
+		return nil, false
+	}
+	if from != "" || to != "" {
+		c = append(Clause{{Field: b.Fields.Year, From: from, To: to}}, c...)
+	}
+	return c, true
+}
+
+func maxYear(a, b string) string {
+	if a == "" || b > a {
+		return b
+	}
+	return a
+}
+
+func minYear(a, b string) string {
+	if a == "" || b < a {
+		return b
+	}
+	return a
+}
+
+// field returns the index field of the identifiers of a group.
+func (g Group) field(f Fields) string {
+	if g.ISBN {
+		return f.ISBN
+	}
+	return f.ISSN
+}
+
+// Solr returns a filter query for use in fq.
+func Solr(groups []Group, f Fields) string {
+	var parts []string
+	for _, g := range groups {
+		var ids []string
+		for _, id := range g.IDs {
+			ids = append(ids, strconv.Quote(id))
+		}
+		part := fmt.Sprintf("%s:(%s)", g.field(f), strings.Join(ids, " OR "))
+		if cs := solrClauses(g.Clauses); cs != "" {
+			part = fmt.Sprintf("(%s AND %s)", part, cs)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " OR ")
+}
+
+// solrClauses returns the disjunction of clauses or the empty string, if
+// any clause matches everything.
+func solrClauses(clauses []Clause) string {
+	var parts []string
+	for _, c := range clauses {
+		if len(c) == 0 {
+			return ""
+		}
+		var ranges []string
+		for _, r := range c {
+			ranges = append(ranges, fmt.Sprintf("%s:[%s TO %s]", r.Field, bound(r.From), bound(r.To)))
+		}
+		parts = append(parts, strings.Join(ranges, " AND "))
+	}
+	if len(parts) == 1 {
+		return "(" + parts[0] + ")"
+	}
+	return "((" + strings.Join(parts, ") OR (") + "))"
+}
+
+func bound(s string) string {
+	if s == "" {
+		return "*"
+	}
+	return s
+}
+
+// object is a JSON object.
+type object map[string]interface{}
+
+// Elasticsearch returns a bool query for use in a filter context.
+func Elasticsearch(groups []Group, f Fields) interface{} {
+	var should []interface{}
+	for _, g := range groups {
+		filter := []interface{}{object{"terms": object{g.field(f): g.IDs}}}
+		if cs := esClauses(g.Clauses); cs != nil {
+			filter = append(filter, cs)
+		}
+		should = append(should, object{"bool": object{"filter": filter}})
+	}
+	return object{"bool": object{"should": should, "minimum_should_match": 1}}
+}
+
+// esClauses returns the disjunction of clauses or nil, if any clause matches
+// everything.
+func esClauses(clauses []Clause) interface{} {
+	var should []interface{}
+	for _, c := range clauses {
+		if len(c) == 0 {
+			return nil
+		}
+		var filter []interface{}
+		for _, r := range c {
+			bounds := object{}
+			if r.From != "" {
+				bounds["gte"] = value(r.From)
+			}
+			if r.To != "" {
+				bounds["lte"] = value(r.To)
+			}
+			filter = append(filter, object{"range": object{r.Field: bounds}})
+		}
+		should = append(should, object{"bool": object{"filter": filter}})
+	}
+	return object{"bool": object{"should": should, "minimum_should_match": 1}}
+}
+
+// value returns numbers as numbers and anything else as string.
+func value(s string) interface{} {
+	if i, err := strconv.Atoi(s); err == nil {
+		return i
+	}
+	return s
+}
+
+// WriteSolr writes a Solr filter query.
+func WriteSolr(w io.Writer, groups []Group, f Fields) error {
+	_, err := fmt.Fprintln(w, Solr(groups, f))
+	return err
+}
+
+// WriteElasticsearch writes an Elasticsearch bool query as JSON.
+func WriteElasticsearch(w io.Writer, groups []Group, f Fields) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Elasticsearch(groups, f))
+}
@@ -0,0 +1,94 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miku/holdings"
+)
+
+var entries = holdings.Entries{
+	"0006-2499": []holdings.License{holdings.Entry{Begin: holdings.Signature{Date: "1995"}, End: holdings.Signature{Date: "2003"}}},
+	"1613-4141": []holdings.License{holdings.Entry{Begin: holdings.Signature{Date: "1995-03"}, End: holdings.Signature{Date: "2003"}}},
+	"2345-6789": []holdings.License{holdings.Entry{Begin: holdings.Signature{Date: "2000"}, Embargo: -holdings.Year}},
+	"3456-7890": []holdings.License{holdings.Entry{Begin: holdings.Signature{Volume: "10"}}},
+	"4567-8901": []holdings.License{holdings.Entry{}},
+	"5678-9012": []holdings.License{
+		holdings.Entry{Begin: holdings.Signature{Volume: "1"}, End: holdings.Signature{Volume: "10"}},
+		holdings.Entry{Begin: holdings.Signature{Date: "1990"}, End: holdings.Signature{Date: "1995"}},
+	},
+}
+
+func TestGroups(t *testing.T) {
+	b := Builder{Fields: DefaultFields, Now: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)}
+	groups := b.Groups(entries)
+	want := []Group{
+		{IDs: []string{"0006-2499", "1613-4141"}, Clauses: []Clause{{{Field: "publishDateSort", From: "1995", To: "2003"}}}},
+		{IDs: []string{"2345-6789"}, Clauses: []Clause{{{Field: "publishDateSort", From: "2000", To: "2024"}}}},
+		{IDs: []string{"5678-9012"}, Clauses: []Clause{{{Field: "publishDateSort", From: "1990", To: "1995"}}}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Fatalf("Groups: got %+v, want %+v", groups, want)
+	}
+
+	solr := Solr(groups, DefaultFields)
+	wantSolr := `(issn:("0006-2499" OR "1613-4141") AND (publishDateSort:[1995 TO 2003])) OR ` +
+		`(issn:("2345-6789") AND (publishDateSort:[2000 TO 2024])) OR ` +
+		`(issn:("5678-9012") AND (publishDateSort:[1990 TO 1995]))`
+	if solr != wantSolr {
+		t.Errorf("Solr: got %s, want %s", solr, wantSolr)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteElasticsearch(&buf, groups[1:2], DefaultFields); err != nil {
+		t.Fatal(err)
+	}
+	var got, wantES interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal([]byte(`{"bool": {"minimum_should_match": 1, "should": [
+	  {"bool": {"filter": [
+	    {"terms": {"issn": ["2345-6789"]}},
+	    {"bool": {"minimum_should_match": 1, "should": [
+	      {"bool": {"filter": [{"range": {"publishDateSort": {"gte": 2000, "lte": 2024}}}]}}
+	    ]}}
+	  ]}}
+	]}}`), &wantES)
+	if !reflect.DeepEqual(got, wantES) {
+		t.Errorf("Elasticsearch: got %s", buf.String())
+	}
+}
+
+func TestClauseEmbargo(t *testing.T) {
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	fields := Fields{ISSN: "issn", Year: "year", Date: "date"}
+	b := Builder{Fields: fields, Now: now}
+	c, ok := b.Clause(holdings.Entry{Begin: holdings.Signature{Date: "2000"}, Embargo: -holdings.Year, EmbargoDisallowEarlier: true})
+	want := Clause{{Field: "year", From: "2000"}, {Field: "date", From: "2025-10-19T00:00:00Z"}}
+	if !ok || !reflect.DeepEqual(c, want) {
+		t.Errorf("Clause: got %v, %v, want %v", c, ok, want)
+	}
+	if _, ok := b.Clause(holdings.Entry{Embargo: -holdings.Year}); ok {
+		t.Errorf("Clause: got a clause for an entry without dates")
+	}
+	b.Fields.Date = ""
+	if _, ok := b.Clause(holdings.Entry{End: holdings.Signature{Date: "2020"}, Embargo: -holdings.Year, EmbargoDisallowEarlier: true}); ok {
+		t.Errorf("Clause: got a clause for coverage entirely behind a rolling wall")
+	}
+}
+
+func TestGroupsBooks(t *testing.T) {
+	b := Builder{Fields: DefaultFields, Now: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)}
+	groups := b.Groups(holdings.Entries{"9783161484100": []holdings.License{holdings.Book{Edition: "2"}}})
+	want := []Group{{IDs: []string{"9783161484100"}, Clauses: []Clause{nil}, ISBN: true}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Fatalf("Groups: got %+v, want %+v", groups, want)
+	}
+	if got, want := Solr(groups, DefaultFields), `isbn:("9783161484100")`; got != want {
+		t.Errorf("Solr: got %s, want %s", got, want)
+	}
+}
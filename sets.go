@@ -0,0 +1,95 @@
+package holdings
+
+// Merge returns the coverage of a and b combined. Per identifier,
+// overlapping and adjacent intervals are joined and parts, that are already
+// granted by an interval with a more generous moving wall, are dropped.
+// Licenses of type Entry and Book take part in set operations, books are
+// compared as a whole. Other license implementations are left out.
+func Merge(a, b Entries) Entries {
+	result := make(Entries)
+	for _, e := range []Entries{a, b} {
+		for id, licenses := range e {
+			result[id] = append(result[id], licenses...)
+		}
+	}
+	for id, licenses := range result {
+		merged := licensesOf(mergeEntries(entriesOf(licenses)), booksOf(licenses))
+		if len(merged) == 0 {
+			delete(result, id)
+			continue
+		}
+		result[id] = merged
+	}
+	return result
+}
+
+// mergeEntries normalizes entries and removes redundant parts.
+func mergeEntries(entries []Entry) []Entry {
+	if len(entries) == 0 {
+		return nil
+	}
+	normalized := normalize(entries)
+	var result []Entry
+	for i, x := range normalized {
+		var ys []Entry
+		for j, y := range normalized {
+			if i != j && wallOf(x) != wallOf(y) && wallGrants(y, x) {
+				ys = append(ys, y)
+			}
+		}
+		result = append(result, subtractAll([]Entry{x}, ys)...)
+	}
+	return normalize(result)
+}
+
+// Intersect returns the coverage present in both a and b. Common intervals
+// get the stricter of both moving walls.
+func Intersect(a, b Entries) Entries {
+	result := make(Entries)
+	for id, la := range a {
+		lb, ok := b[id]
+		if !ok {
+			continue
+		}
+		xs, ys := mergeEntries(entriesOf(la)), mergeEntries(entriesOf(lb))
+		var common []Entry
+		for _, x := range xs {
+			for _, y := range ys {
+				if e, ok := intersectEntry(x, y); ok {
+					common = append(common, e)
+				}
+			}
+		}
+		var books []Book
+		for _, x := range booksOf(la) {
+			if containsBook(booksOf(lb), x) {
+				books = append(books, x)
+			}
+		}
+		if merged := licensesOf(mergeEntries(common), books); len(merged) > 0 {
+			result[id] = merged
+		}
+	}
+	return result
+}
+
+// Subtract returns the coverage of a, that b does not provide. An interval
+// of b only counts, if its moving wall grants at least as much as the one in
+// a, so the result errs on the side of reporting too much.
+func Subtract(a, b Entries) Entries {
+	result := make(Entries)
+	for id, la := range a {
+		xs := mergeEntries(entriesOf(la))
+		rest := subtractAll(xs, mergeEntries(entriesOf(b[id])))
+		var books []Book
+		for _, x := range booksOf(la) {
+			if !containsBook(booksOf(b[id]), x) {
+				books = append(books, x)
+			}
+		}
+		if merged := licensesOf(normalize(rest), books); len(merged) > 0 {
+			result[id] = merged
+		}
+	}
+	return result
+}
@@ -0,0 +1,131 @@
+package holdings
+
+import (
+	"reflect"
+	"testing"
+)
+
+// span is a shortcut for an entry covering years.
+func span(begin, end string) Entry {
+	return Entry{Begin: Signature{Date: begin}, End: Signature{Date: end}}
+}
+
+func TestSetOperations(t *testing.T) {
+	embargoed := span("2000", "")
+	embargoed.Embargo = -1 * Year
+
+	var cases = []struct {
+		about string
+		op    func(a, b Entries) Entries
+		a, b  []License
+		want  []License
+	}{
+		{
+			about: "merge joins overlapping and adjacent intervals",
+			op:    Merge,
+			a:     []License{span("1990", "1995"), span("2000", "2005")},
+			b:     []License{span("1994", "1999"), span("2010", "")},
+			want:  []License{span("1990", "2005"), span("2010", "")},
+		},
+		{
+			about: "merge drops parts granted by a more generous wall",
+			op:    Merge,
+			a:     []License{span("1990", "2005")},
+			b:     []License{embargoed},
+			want: []License{span("1990", "2005"), Entry{
+				Begin: Signature{Date: "2006"}, Embargo: -1 * Year}},
+		},
+		{
+			about: "intersect keeps common coverage",
+			op:    Intersect,
+			a:     []License{span("1990", "2000"), span("2005", "")},
+			b:     []License{span("1995", "2010")},
+			want:  []License{span("1995", "2000"), span("2005", "2010")},
+		},
+		{
+			about: "intersect uses the stricter wall",
+			op:    Intersect,
+			a:     []License{span("1990", "")},
+			b:     []License{embargoed},
+			want:  []License{embargoed},
+		},
+		{
+			about: "subtract cuts out covered years",
+			op:    Subtract,
+			a:     []License{span("1990", "")},
+			b:     []License{span("1995", "2000")},
+			want:  []License{span("1990", "1994"), span("2001", "")},
+		},
+		{
+			about: "subtract ignores coverage with a stricter wall",
+			op:    Subtract,
+			a:     []License{span("2000", "")},
+			b:     []License{embargoed},
+			want:  []License{span("2000", "")},
+		},
+		{
+			about: "subtract works with volumes and issues",
+			op:    Subtract,
+			a: []License{Entry{
+				Begin: Signature{Date: "2000", Volume: "1", Issue: "1"},
+				End:   Signature{Date: "2005", Volume: "6", Issue: "4"}}},
+			b: []License{Entry{
+				Begin: Signature{Date: "2002", Volume: "3", Issue: "2"},
+				End:   Signature{Date: "2005", Volume: "6", Issue: "4"}}},
+			want: []License{Entry{
+				Begin: Signature{Date: "2000", Volume: "1", Issue: "1"},
+				End:   Signature{Date: "2002", Volume: "3", Issue: "1"}}},
+		},
+		{
+			about: "subtract everything",
+			op:    Subtract,
+			a:     []License{span("1990", "2000")},
+			b:     []License{span("1985", "")},
+			want:  nil,
+		},
+		{
+			about: "volumes do not cut out years",
+			op:    Subtract,
+			a:     []License{span("1990", "2000")},
+			b:     []License{Entry{Begin: Signature{Volume: "1"}}},
+			want:  []License{span("1990", "2000")},
+		},
+		{
+			about: "merge keeps volumes and years apart",
+			op:    Merge,
+			a:     []License{Entry{Begin: Signature{Volume: "1"}, End: Signature{Volume: "10"}}},
+			b:     []License{span("1990", "1995")},
+			want: []License{span("1990", "1995"),
+				Entry{Begin: Signature{Volume: "1"}, End: Signature{Volume: "10"}}},
+		},
+		{
+			about: "merge keeps books once",
+			op:    Merge,
+			a:     []License{Book{Edition: "2"}},
+			b:     []License{Book{Edition: "2"}, Book{Edition: "3"}},
+			want:  []License{Book{Edition: "2"}, Book{Edition: "3"}},
+		},
+		{
+			about: "intersect keeps common books",
+			op:    Intersect,
+			a:     []License{Book{Edition: "2"}, Book{Edition: "3"}},
+			b:     []License{Book{Edition: "3"}},
+			want:  []License{Book{Edition: "3"}},
+		},
+		{
+			about: "subtract removes books",
+			op:    Subtract,
+			a:     []License{Book{Edition: "2"}, Book{Edition: "3"}},
+			b:     []License{Book{Edition: "3"}},
+			want:  []License{Book{Edition: "2"}},
+		},
+	}
+
+	for _, c := range cases {
+		a, b := Entries{"x": c.a}, Entries{"x": c.b}
+		got := c.op(a, b).Licenses("x")
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.about, got, c.want)
+		}
+	}
+}
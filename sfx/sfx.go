@@ -0,0 +1,330 @@
+// Package sfx reads link resolver exports, that express coverage as SFX
+// threshold expressions, e.g.
+//
+//	$obj->parsedDate(">=","1995",undef,undef) && $obj->parsedDate("<=","2003","12","4")
+//	$obj->timediff('>=','1y')
+//
+// Conditions joined by && form a single entry, alternatives joined by ||
+// form several entries. Coverage without a date bound is open, so it
+// covers any date.
+package sfx
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miku/holdings"
+)
+
+var (
+	ErrThreshold          = errors.New("cannot parse threshold")
+	ErrMissingColumns     = errors.New("missing identifier or threshold columns")
+	ErrMissingIdentifiers = errors.New("missing identifiers")
+)
+
+var (
+	// $obj->parsedDate(">=","1995",undef,undef)
+	parsedDatePattern = regexp.MustCompile(`^\$obj->parsedDate\(\s*(.+?)\s*\)$`)
+	// $obj->timediff('>=','1y')
+	timediffPattern = regexp.MustCompile(`^\$obj->timediff\(\s*['"]([<>]=?)['"]\s*,\s*['"](\d+)([ymd])['"]\s*\)$`)
+)
+
+// Columns lists the accepted header names per field. For the threshold, the
+// first non-empty column wins, so local thresholds override global ones.
+var Columns = map[string][]string{
+	"issn":      {"issn", "print_issn", "print_identifier"},
+	"eissn":     {"eissn", "e_issn", "online_issn", "online_identifier"},
+	"title":     {"title", "publication_title"},
+	"threshold": {"threshold_active", "threshold_local", "threshold", "threshold_global"},
+}
+
+// ParseThreshold parses a threshold expression into entries. An empty
+// threshold means unrestricted coverage, which, like an entry without date
+// bounds, is open. Strict comparisons exclude the given signature, e.g. >
+// 1995 begins in 1996.
+func ParseThreshold(s string) ([]holdings.Entry, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return []holdings.Entry{{Open: true}}, nil
+	}
+	var entries []holdings.Entry
+	for _, alternative := range split(s, "||") {
+		var entry holdings.Entry
+		for _, cond := range split(alternative, "&&") {
+			if err := apply(&entry, cond); err != nil {
+				return nil, err
+			}
+		}
+		if entry.Begin.Date == "" && entry.End.Date == "" {
+			entry.Open = true
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// split splits an expression at a top level operator and removes enclosing
+// parentheses of the parts.
+func split(s, op string) []string {
+	var parts []string
+	var depth, start int
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], op):
+			parts = append(parts, unwrap(s[start:i]))
+			start = i + len(op)
+			i += len(op) - 1
+		}
+	}
+	return append(parts, unwrap(s[start:]))
+}
+
+// unwrap trims whitespace and parentheses around a whole expression.
+func unwrap(s string) string {
+	s = strings.TrimSpace(s)
+	for strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		// only strip, if the parentheses belong together
+		depth := 0
+		for i := 0; i < len(s)-1; i++ {
+			switch s[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				return s
+			}
+		}
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// apply narrows an entry by a single condition.
+func apply(entry *holdings.Entry, cond string) error {
+	cond = strings.TrimSpace(cond)
+	if ms := timediffPattern.FindStringSubmatch(cond); ms != nil {
+		n, err := strconv.Atoi(ms[2])
+		if err != nil {
+			return ErrThreshold
+		}
+		unit := holdings.Day
+		switch ms[3] {
+		case "y":
+			unit = holdings.Year
+		case "m":
+			unit = holdings.Month
+		}
+		entry.Embargo = -time.Duration(n) * unit
+		// '>=' means the item must be at least that old, an embargo; '<='
+		// means only recent items are available
+		entry.EmbargoDisallowEarlier = strings.HasPrefix(ms[1], "<")
+		return nil
+	}
+	ms := parsedDatePattern.FindStringSubmatch(cond)
+	if ms == nil {
+		return ErrThreshold
+	}
+	args := strings.Split(ms[1], ",")
+	if len(args) != 4 {
+		return ErrThreshold
+	}
+	for i, a := range args {
+		a = strings.TrimSpace(a)
+		if a == "undef" {
+			a = ""
+		}
+		args[i] = strings.Trim(a, `"'`)
+	}
+	sig := holdings.Signature{Date: args[1], Volume: args[2], Issue: args[3]}
+	switch args[0] {
+	case ">=":
+		entry.Begin = sig
+	case ">":
+		entry.Begin = holdings.Successor(sig)
+	case "<=":
+		entry.End = sig
+	case "<":
+		entry.End = holdings.Predecessor(sig)
+	case "==", "=":
+		entry.Begin, entry.End = sig, sig
+	default:
+		return ErrThreshold
+	}
+	return nil
+}
+
+// Reader reads tab separated link resolver exports with a header row. The
+// columns are found by the names in Columns. A row without ISSN or with a
+// threshold, that does not parse, is handed to Policy; the default policy
+// skips it and reports all such rows at the end in a holdings.ParseError.
+type Reader struct {
+	r      *bufio.Reader
+	Policy holdings.ErrorPolicy
+	line   int
+	raw    string
+	index  map[string]int
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		r:      bufio.NewReader(r),
+		Policy: holdings.ErrorPolicy{Action: holdings.SkipAndCollect},
+	}
+}
+
+// Row is a single row of an export.
+type Row struct {
+	Title     string
+	ISSN      string
+	EISSN     string
+	Threshold string
+}
+
+// Identifiers returns the valid looking ISSNs of a row.
+func (row Row) Identifiers() []string {
+	var ids []string
+	for _, s := range []string{row.ISSN, row.EISSN} {
+		if issn, ok := holdings.NormalizeISSN(s); ok {
+			ids = append(ids, issn)
+		}
+	}
+	return ids
+}
+
+// header reads the header row and finds the columns.
+func (r *Reader) header() error {
+	line, err := r.r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	r.line++
+	r.index = make(map[string]int)
+	for i, name := range strings.Split(strings.TrimRight(line, "\r\n"), "\t") {
+		r.index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	has := func(field string) bool {
+		for _, name := range Columns[field] {
+			if _, ok := r.index[name]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	if !has("threshold") || !has("issn") && !has("eissn") {
+		return ErrMissingColumns
+	}
+	return nil
+}
+
+// Read returns the next row. At the end of the input io.EOF is returned.
+func (r *Reader) Read() (Row, error) {
+	var row Row
+	if r.index == nil {
+		if err := r.header(); err != nil {
+			return row, err
+		}
+	}
+	var line string
+	var err error
+	for {
+		line, err = r.r.ReadString('\n')
+		r.line++
+		r.raw = line
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+		if err != nil {
+			return row, err
+		}
+	}
+	if err != nil && err != io.EOF {
+		return row, err
+	}
+	record := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
+	get := func(field string) string {
+		for _, name := range Columns[field] {
+			if i, ok := r.index[name]; ok && i < len(record) {
+				if v := strings.TrimSpace(record[i]); v != "" {
+					return v
+				}
+			}
+		}
+		return ""
+	}
+	return Row{
+		Title:     get("title"),
+		ISSN:      get("issn"),
+		EISSN:     get("eissn"),
+		Threshold: get("threshold"),
+	}, nil
+}
+
+// Line returns the line number of the row last read.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+func (r *Reader) ReadAll() (holdings.Entries, error) {
+	entries := make(holdings.Entries)
+
+	perr := holdings.ParseError{}
+
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+		ids := row.Identifiers()
+		parsed, err := ParseThreshold(row.Threshold)
+		if err == nil && len(ids) == 0 {
+			err = ErrMissingIdentifiers
+		}
+		if err != nil {
+			rerr := &holdings.RecordError{
+				Line:    r.line,
+				Record:  row.ISSN,
+				Snippet: strings.TrimRight(r.raw, "\r\n"),
+				Err:     err,
+			}
+			if rerr.Record == "" {
+				rerr.Record = row.EISSN
+			}
+			if err := r.Policy.Handle(&perr, rerr); err != nil {
+				return entries, err
+			}
+			continue
+		}
+		for _, entry := range parsed {
+			entry.Title = row.Title
+			for _, id := range ids {
+				entries[id] = append(entries[id], entry)
+			}
+		}
+	}
+	if len(perr.Errors) > 0 {
+		return entries, perr
+	}
+	return entries, nil
+}
@@ -0,0 +1,148 @@
+package sfx
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/miku/holdings"
+)
+
+func TestParseThreshold(t *testing.T) {
+	var cases = []struct {
+		s    string
+		want []holdings.Entry
+		err  error
+	}{
+		{"", []holdings.Entry{{Open: true}}, nil},
+		{
+			`$obj->parsedDate(">=","1995",undef,undef) && $obj->parsedDate("<=","2003","12","4")`,
+			[]holdings.Entry{{
+				Begin: holdings.Signature{Date: "1995"},
+				End:   holdings.Signature{Date: "2003", Volume: "12", Issue: "4"},
+			}},
+			nil,
+		},
+		{
+			`$obj->timediff('>=','1y')`,
+			[]holdings.Entry{{Open: true, Embargo: -holdings.Year}},
+			nil,
+		},
+		{
+			`$obj->parsedDate(">","1995",undef,undef) && $obj->parsedDate("<","2003","12","4")`,
+			[]holdings.Entry{{
+				Begin: holdings.Signature{Date: "1996"},
+				End:   holdings.Signature{Date: "2003", Volume: "12", Issue: "3"},
+			}},
+			nil,
+		},
+		{
+			`$obj->parsedDate('>=','2001','5',undef) && $obj->timediff('<=','6m')`,
+			[]holdings.Entry{{
+				Begin:                  holdings.Signature{Date: "2001", Volume: "5"},
+				Embargo:                -6 * holdings.Month,
+				EmbargoDisallowEarlier: true,
+			}},
+			nil,
+		},
+		{
+			`($obj->parsedDate("<=","1990",undef,undef)) || ($obj->parsedDate(">=","2000",undef,undef) && $obj->timediff('>=','30d'))`,
+			[]holdings.Entry{
+				{End: holdings.Signature{Date: "1990"}},
+				{Begin: holdings.Signature{Date: "2000"}, Embargo: -30 * holdings.Day},
+			},
+			nil,
+		},
+		{`$obj->isFree()`, nil, ErrThreshold},
+		{`$obj->parsedDate("!=","1995",undef,undef)`, nil, ErrThreshold},
+	}
+	for _, c := range cases {
+		got, err := ParseThreshold(c.s)
+		if err != c.err {
+			t.Errorf("ParseThreshold(%q): got %v, want %v", c.s, err, c.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ParseThreshold(%q): got %+v, want %+v", c.s, got, c.want)
+		}
+	}
+}
+
+const export = "TITLE\tISSN\tEISSN\tTHRESHOLD_GLOBAL\tTHRESHOLD_LOCAL\n" +
+	"Journal of Things\t0006-2499\t\t$obj->parsedDate(\">=\",\"1995\",undef,undef)\t$obj->parsedDate(\">=\",\"2000\",undef,undef)\n" +
+	"\n" +
+	"Annals of Stuff\t\t1613-4141\t$obj->timediff('>=','1y')\t\n" +
+	"Broken\t1234-5679\t\t$obj->whatever()\t\n"
+
+func TestReadAll(t *testing.T) {
+	entries, err := NewReader(strings.NewReader(export)).ReadAll()
+	var perr holdings.ParseError
+	if !errors.As(err, &perr) || len(perr.Errors) != 1 || !errors.Is(err, ErrThreshold) {
+		t.Fatalf("ReadAll: got %v, want a single threshold error", err)
+	}
+	want := holdings.Entries{
+		"0006-2499": []holdings.License{holdings.Entry{
+			Begin: holdings.Signature{Date: "2000"},
+			Title: "Journal of Things",
+		}},
+		"1613-4141": []holdings.License{holdings.Entry{
+			Embargo: -holdings.Year,
+			Open:    true,
+			Title:   "Annals of Stuff",
+		}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ReadAll: got %+v, want %+v", entries, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	report, err := NewReader(strings.NewReader(export)).Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Records != 3 {
+		t.Errorf("Validate: got %d records, want 3", report.Records)
+	}
+	var rules []string
+	for _, p := range report.Problems {
+		rules = append(rules, p.Rule)
+	}
+	if want := []string{RuleThreshold}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("Validate: got %v, want %v", rules, want)
+	}
+	for _, header := range []string{"title\tnotes\n", "issn\teissn\n", "title\tthreshold\n"} {
+		report, err = NewReader(strings.NewReader(header)).Validate()
+		if err != nil || len(report.Problems) != 1 || report.Problems[0].Rule != RuleColumns {
+			t.Errorf("Validate(%q): got %v, %v, want a columns problem", header, report.Problems, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	doc := "issn\tthreshold\n" +
+		"0006-2499\t\n" +
+		"1613-4141\t$obj->timediff('>=','1y')\n" +
+		"2345-6789\t$obj->parsedDate(\">\",\"1995\",undef,undef)\n"
+	entries, err := NewReader(strings.NewReader(doc)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cases = []struct {
+		id   string
+		date string
+		ok   bool
+	}{
+		{"0006-2499", "1850", true},
+		{"1613-4141", "2001", true},
+		{"2345-6789", "1995", false},
+		{"2345-6789", "1996", true},
+	}
+	for _, c := range cases {
+		r := holdings.Record{Identifiers: []string{c.id}, Signature: holdings.Signature{Date: c.date}}
+		if d := holdings.Check(entries, r); d.OK != c.ok {
+			t.Errorf("Check(%s, %s): got %v (%v), want %v", c.id, c.date, d.OK, d.Err, c.ok)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package sfx
+
+import (
+	"io"
+
+	"github.com/miku/holdings"
+)
+
+// Rule names, see Rules for a description.
+const (
+	RuleColumns    = "columns"
+	RuleIdentifier = "identifier"
+	RuleISSN       = "issn"
+	RuleThreshold  = "threshold"
+	RuleDateOrder  = "date-order"
+)
+
+// Rules is the catalog of checks performed by Validate.
+var Rules = holdings.Catalog{
+	{Name: RuleColumns, Severity: holdings.Error, Description: "header must name identifier and threshold columns"},
+	{Name: RuleIdentifier, Severity: holdings.Error, Description: "row must have a print or online ISSN"},
+	{Name: RuleISSN, Severity: holdings.Error, Description: "ISSN must have a valid check digit"},
+	{Name: RuleThreshold, Severity: holdings.Error, Description: "threshold must consist of parsedDate and timediff conditions"},
+	{Name: RuleDateOrder, Severity: holdings.Error, Description: "threshold must not begin after it ends"},
+}
+
+// Validate reads the remaining input and checks every row. Problems are
+// located by line. The returned error is only non-nil for I/O errors.
+func (r *Reader) Validate() (holdings.Report, error) {
+	v := holdings.Collector{Rules: Rules}
+	for {
+		row, err := r.Read()
+		v.At.Line = r.line
+		if err == io.EOF {
+			break
+		}
+		if err == ErrMissingColumns {
+			v.Add(RuleColumns, "", "", err.Error())
+			return v.Report, nil
+		}
+		if err != nil {
+			return v.Report, err
+		}
+		v.Report.Records++
+		if len(row.Identifiers()) == 0 {
+			v.Add(RuleIdentifier, "", "", "no ISSN")
+		}
+		for _, f := range [][2]string{{"issn", row.ISSN}, {"eissn", row.EISSN}} {
+			if f[1] != "" && !holdings.ValidISSN(f[1]) {
+				v.Add(RuleISSN, f[0], f[1], "invalid ISSN")
+			}
+		}
+		entries, err := ParseThreshold(row.Threshold)
+		if err != nil {
+			v.Add(RuleThreshold, "threshold", row.Threshold, err.Error())
+			continue
+		}
+		for _, e := range entries {
+			if e.Begin.Date != "" && e.End.Date != "" && holdings.CompareDates(e.Begin.Date, e.End.Date) > 0 {
+				v.Add(RuleDateOrder, "threshold", row.Threshold, "end before begin "+e.Begin.Date)
+			}
+		}
+	}
+	return v.Report, nil
+}
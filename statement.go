@@ -0,0 +1,193 @@
+package holdings
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// vocabulary holds the words of a holdings statement in one language.
+type vocabulary struct {
+	volume, issue string
+	// unit is singular and plural per moving wall unit
+	unit map[time.Duration][2]string
+	// embargo and rolling are patterns for a moving wall, taking the count
+	// and the unit
+	embargo, rolling string
+	// rollingOne replaces rolling for a count of one, if the grammar
+	// requires it
+	rollingOne map[time.Duration]string
+	// edition names an edition of a book by number
+	edition func(n int) string
+}
+
+var vocabularies = map[string]vocabulary{
+	"en": {
+		volume: "vol.",
+		issue:  "no.",
+		unit: map[time.Duration][2]string{
+			Year:  {"year", "years"},
+			Month: {"month", "months"},
+			Day:   {"day", "days"},
+		},
+		embargo: "most recent %d %s not available",
+		rolling: "only most recent %d %s available",
+		edition: func(n int) string {
+			suffix := "th"
+			switch {
+			case n%100 >= 11 && n%100 <= 13:
+			case n%10 == 1:
+				suffix = "st"
+			case n%10 == 2:
+				suffix = "nd"
+			case n%10 == 3:
+				suffix = "rd"
+			}
+			return fmt.Sprintf("%d%s ed.", n, suffix)
+		},
+	},
+	"de": {
+		volume: "Bd.",
+		issue:  "H.",
+		unit: map[time.Duration][2]string{
+			Year:  {"Jahr", "Jahre"},
+			Month: {"Monat", "Monate"},
+			Day:   {"Tag", "Tage"},
+		},
+		embargo: "Sperrfrist %d %s",
+		rolling: "nur die letzten %d %s verfügbar",
+		rollingOne: map[time.Duration]string{
+			Year:  "nur das letzte Jahr verfügbar",
+			Month: "nur der letzte Monat verfügbar",
+			Day:   "nur der letzte Tag verfügbar",
+		},
+		edition: func(n int) string {
+			return fmt.Sprintf("%d. Aufl.", n)
+		},
+	},
+}
+
+// Statement renders the normalized coverage of a list of licenses as a
+// holdings statement following Z39.71 and DIN conventions, e.g. "Vol. 10,
+// no. 123 (2009) – vol. 12, no. 234 (2011); most recent 1 year not
+// available". Ranges are separated by a comma, an open end is left blank. A
+// moving wall note follows the range it applies to, or the last range, if
+// all ranges share it. Books are described by edition and year, e.g. "2nd
+// ed. (2015)". Supported languages are "de" and "en", other languages fall
+// back to English. Other license implementations are left out.
+func Statement(licenses []License, lang string) string {
+	voc, ok := vocabularies[strings.ToLower(strings.SplitN(lang, "-", 2)[0])]
+	if !ok {
+		voc = vocabularies["en"]
+	}
+	var parts []string
+	if s := voc.ranges(Normalize(licenses)); s != "" {
+		parts = append(parts, s)
+	}
+	for _, b := range booksOf(licenses) {
+		if s := voc.book(b); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	s := strings.Join(parts, "; ")
+	if s == "" {
+		return ""
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// ranges renders normalized coverage intervals.
+func (v vocabulary) ranges(entries []Entry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	shared := true
+	for _, e := range entries[1:] {
+		if wallOf(e) != wallOf(entries[0]) {
+			shared = false
+		}
+	}
+	var ranges []string
+	for _, e := range entries {
+		s := strings.TrimSpace(v.signature(e.Begin) + " – " + v.signature(e.End))
+		if note := v.wall(e); note != "" && !shared {
+			s += "; " + note
+		}
+		ranges = append(ranges, s)
+	}
+	s := strings.Join(ranges, ", ")
+	if note := v.wall(entries[0]); note != "" && shared {
+		s += "; " + note
+	}
+	return s
+}
+
+// book renders edition and year of a book, e.g. 2nd ed. (2015).
+func (v vocabulary) book(b Book) string {
+	edition := b.Edition
+	if n := findInt(edition); n > 0 {
+		edition = v.edition(n)
+	}
+	year := yearOf(b.Date)
+	switch {
+	case year == "":
+		return edition
+	case edition == "":
+		return year
+	default:
+		return fmt.Sprintf("%s (%s)", edition, year)
+	}
+}
+
+// signature renders enumeration and chronology, e.g. vol. 10, no. 123 (2009).
+func (v vocabulary) signature(s Signature) string {
+	var parts []string
+	if s.Volume != "" {
+		parts = append(parts, v.volume+" "+s.Volume)
+	}
+	if s.Issue != "" {
+		parts = append(parts, v.issue+" "+s.Issue)
+	}
+	enum := strings.Join(parts, ", ")
+	switch {
+	case s.Date == "":
+		return enum
+	case enum == "":
+		return s.Date
+	default:
+		return fmt.Sprintf("%s (%s)", enum, s.Date)
+	}
+}
+
+// wall describes the moving wall of an entry or returns the empty string.
+func (v vocabulary) wall(e Entry) string {
+	d := e.Embargo
+	if d == 0 {
+		return ""
+	}
+	if d < 0 {
+		d = -d
+	}
+	unit := Day
+	switch {
+	case d%Year == 0:
+		unit = Year
+	case d%Month == 0:
+		unit = Month
+	}
+	n := int((d + unit - 1) / unit)
+	name := v.unit[unit][1]
+	if n == 1 {
+		name = v.unit[unit][0]
+	}
+	if e.EmbargoDisallowEarlier {
+		if one, ok := v.rollingOne[unit]; ok && n == 1 {
+			return one
+		}
+		return fmt.Sprintf(v.rolling, n, name)
+	}
+	return fmt.Sprintf(v.embargo, n, name)
+}
@@ -0,0 +1,40 @@
+package holdings
+
+import "testing"
+
+func TestStatement(t *testing.T) {
+	full := Entry{
+		Begin:   Signature{Date: "2009", Volume: "10", Issue: "123"},
+		End:     Signature{Date: "2011", Volume: "12", Issue: "234"},
+		Embargo: -1 * Year,
+	}
+	rolling := span("1995", "")
+	rolling.Embargo = -6 * Month
+	rolling.EmbargoDisallowEarlier = true
+
+	var cases = []struct {
+		licenses []License
+		lang     string
+		want     string
+	}{
+		{nil, "en", ""},
+		{[]License{full}, "en", "Vol. 10, no. 123 (2009) – vol. 12, no. 234 (2011); most recent 1 year not available"},
+		{[]License{full}, "de", "Bd. 10, H. 123 (2009) – Bd. 12, H. 234 (2011); Sperrfrist 1 Jahr"},
+		{[]License{full}, "xx", "Vol. 10, no. 123 (2009) – vol. 12, no. 234 (2011); most recent 1 year not available"},
+		{[]License{span("1990", "1995"), span("2000", "")}, "en", "1990 – 1995, 2000 –"},
+		{[]License{span("", "1995")}, "de-DE", "– 1995"},
+		{[]License{Entry{Open: true, Embargo: -1 * Year}}, "en", "–; most recent 1 year not available"},
+		{[]License{rolling}, "en", "1995 –; only most recent 6 months available"},
+		{[]License{rolling}, "de", "1995 –; nur die letzten 6 Monate verfügbar"},
+		{[]License{span("1990", "1995"), rolling}, "en", "1990 – 1995, 1996 –; only most recent 6 months available"},
+		{[]License{span("1990", "1995"), Entry{Begin: Signature{Volume: "3"}, End: Signature{Volume: "5"}}}, "en", "1990 – 1995, vol. 3 – vol. 5"},
+		{[]License{Book{Edition: "2", Date: "2015-03"}}, "en", "2nd ed. (2015)"},
+		{[]License{Book{Edition: "2nd edition", Date: "2015"}}, "de", "2. Aufl. (2015)"},
+		{[]License{Book{Date: "2015"}, Book{Edition: "11"}}, "en", "2015; 11th ed."},
+	}
+	for _, c := range cases {
+		if got := Statement(c.licenses, c.lang); got != c.want {
+			t.Errorf("Statement(%v, %s): got %q, want %q", c.licenses, c.lang, got, c.want)
+		}
+	}
+}
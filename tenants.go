@@ -0,0 +1,114 @@
+package holdings
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrNoLicense is the reason for a negative decision, if a tenant holds no
+// license for any identifier of a record.
+var ErrNoLicense = errors.New("no license")
+
+// ISIL identifies an institution, e.g. DE-15.
+type ISIL string
+
+// Record is what an index knows about an article: the identifiers of the
+// journal, e.g. print and online ISSN, and its position in the journal.
+// Moving walls are checked against Time; if Time is zero, it is taken from
+// the date of the signature.
+type Record struct {
+	Identifiers []string
+	Signature   Signature
+	Time        time.Time
+}
+
+// time returns the time used for moving walls.
+func (r Record) time() time.Time {
+	if !r.Time.IsZero() {
+		return r.Time
+	}
+	date := r.Signature.Date
+	if len(date) > 10 {
+		// e.g. 2001-03-12T00:00:00Z
+		date = date[:10]
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Decision tells, whether a tenant may show a record. If OK, Identifier and
+// License name the license, that grants access, and Access tells, whether
+// access is free or licensed, if the holding file tells. Otherwise Err gives
+// the reason of the last license checked, e.g. ErrMovingWall, or
+// ErrNoLicense.
+type Decision struct {
+	OK         bool
+	Identifier string
+	License    License
+	Access     AccessType
+	Err        error
+}
+
+// Tenants maps institutions to their holdings, so a single index can serve
+// many libraries.
+type Tenants map[ISIL]Holdings
+
+// ISILs returns the institutions in sorted order.
+func (t Tenants) ISILs() []ISIL {
+	var isils []ISIL
+	for isil := range t {
+		isils = append(isils, isil)
+	}
+	sort.Slice(isils, func(i, j int) bool { return isils[i] < isils[j] })
+	return isils
+}
+
+// Check decides for every tenant, whether it may show a record.
+func (t Tenants) Check(r Record) map[ISIL]Decision {
+	decisions := make(map[ISIL]Decision, len(t))
+	for isil, h := range t {
+		decisions[isil] = Check(h, r)
+	}
+	return decisions
+}
+
+// Allowed returns the sorted institutions, that may show a record.
+func (t Tenants) Allowed(r Record) []ISIL {
+	var allowed []ISIL
+	for isil, d := range t.Check(r) {
+		if d.OK {
+			allowed = append(allowed, isil)
+		}
+	}
+	sort.Slice(allowed, func(i, j int) bool { return allowed[i] < allowed[j] })
+	return allowed
+}
+
+// Check decides, whether holdings grant access to a record. Licenses are
+// tried in order of the identifiers of the record; the first license, that
+// covers the record and whose moving wall allows it, decides.
+func Check(h Holdings, r Record) Decision {
+	d := Decision{Err: ErrNoLicense}
+	t := r.time()
+	for _, id := range r.Identifiers {
+		for _, license := range h.Licenses(id) {
+			if err := license.Covers(r.Signature); err != nil {
+				d.Err = err
+				continue
+			}
+			if err := license.TimeRestricted(t); err != nil {
+				d.Err = err
+				continue
+			}
+			d = Decision{OK: true, Identifier: id, License: license}
+			d.Access = AccessOf(license)
+			return d
+		}
+	}
+	return d
+}
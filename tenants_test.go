@@ -0,0 +1,55 @@
+package holdings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTenantsCheck(t *testing.T) {
+	tenants := Tenants{
+		"DE-15": Entries{
+			"0006-2499": []License{Entry{Begin: Signature{Date: "1995"}, End: Signature{Date: "2003"}}},
+		},
+		"DE-14": Entries{
+			"1613-4141": []License{Entry{Begin: Signature{Date: "2000"}}},
+		},
+		"DE-Ch1": Entries{
+			"0006-2499": []License{Entry{Begin: Signature{Date: "2000"}, Embargo: -Year, EmbargoDisallowEarlier: true}},
+		},
+	}
+	record := Record{Identifiers: []string{"0006-2499", "1613-4141"}, Signature: Signature{Date: "2001"}}
+	decisions := tenants.Check(record)
+	if d := decisions["DE-15"]; !d.OK || d.Identifier != "0006-2499" {
+		t.Errorf("DE-15: got %+v, want access by 0006-2499", d)
+	}
+	if d := decisions["DE-14"]; !d.OK || d.Identifier != "1613-4141" {
+		t.Errorf("DE-14: got %+v, want access by 1613-4141", d)
+	}
+	if d := decisions["DE-Ch1"]; d.OK || d.Err != ErrMovingWall {
+		t.Errorf("DE-Ch1: got %+v, want moving wall", d)
+	}
+	if got, want := tenants.Allowed(record), []ISIL{"DE-14", "DE-15"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Allowed: got %v, want %v", got, want)
+	}
+
+	record = Record{Identifiers: []string{"0006-2499"}, Signature: Signature{Date: "1990"}}
+	decisions = tenants.Check(record)
+	if d := decisions["DE-15"]; d.OK || d.Err != ErrBeforeCoverageInterval {
+		t.Errorf("DE-15: got %+v, want before coverage interval", d)
+	}
+	if d := decisions["DE-14"]; d.OK || d.Err != ErrNoLicense {
+		t.Errorf("DE-14: got %+v, want no license", d)
+	}
+}
+
+func TestCheckSources(t *testing.T) {
+	licensed := Entries{"0006-2499": []License{Entry{Begin: Signature{Date: "1995"}, End: Signature{Date: "2000"}, Access: LicensedAccess}}}
+	free := Entries{"0006-2499": []License{Entry{Begin: Signature{Date: "1998"}, Access: FreeAccess}}}
+	h := Sources{licensed, free}
+	for date, want := range map[string]AccessType{"1996": LicensedAccess, "1999": LicensedAccess, "2005": FreeAccess} {
+		d := Check(h, Record{Identifiers: []string{"0006-2499"}, Signature: Signature{Date: date}})
+		if !d.OK || d.Access != want {
+			t.Errorf("%s: got %+v, want %s access", date, d, want)
+		}
+	}
+}
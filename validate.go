@@ -0,0 +1,189 @@
+package holdings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Validator is implemented by readers, that can check a holdings file
+// without loading it.
+type Validator interface {
+	Validate() (Report, error)
+}
+
+// Severity of a validation problem.
+type Severity int
+
+const (
+	Warning Severity = iota
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	default:
+		return "warning"
+	}
+}
+
+// MarshalText renders the severity as string in JSON.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Rule describes a single validation rule.
+type Rule struct {
+	Name        string   `json:"name"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+}
+
+// Catalog lists the rules a validator checks.
+type Catalog []Rule
+
+// Severity looks up the severity of a rule by name.
+func (c Catalog) Severity(name string) Severity {
+	for _, r := range c {
+		if r.Name == name {
+			return r.Severity
+		}
+	}
+	return Warning
+}
+
+// Problem is a single validation finding. Line and column are 1-based and
+// zero if unknown. Line based formats report the column of a field, XML
+// based formats report the element path and the byte offset of the record.
+type Problem struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Offset   int64    `json:"offset,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Field    string   `json:"field,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Location formats the position of the problem.
+func (p Problem) Location() string {
+	var s string
+	switch {
+	case p.Column > 0:
+		s = fmt.Sprintf("%d:%d", p.Line, p.Column)
+	case p.Line > 0:
+		s = fmt.Sprintf("%d", p.Line)
+	case p.Offset > 0:
+		s = fmt.Sprintf("@%d", p.Offset)
+	}
+	if p.Path != "" {
+		if s != "" {
+			s += " "
+		}
+		s += p.Path
+	}
+	return s
+}
+
+// Report collects the problems found in a file.
+type Report struct {
+	Records  int       `json:"records"`
+	Problems []Problem `json:"problems"`
+}
+
+// Collector collects the problems of a file into a report. The severity
+// of a problem is looked up in Rules, its location is taken from At, which
+// the validator sets to the record being checked.
+type Collector struct {
+	Report Report
+	Rules  Catalog
+	At     Problem
+}
+
+// Add records a problem of the current record. For XML based formats, where
+// is a path below the path of the record, otherwise the name of a field.
+func (c *Collector) Add(rule, where, value, msg string) {
+	p := c.At
+	p.Rule = rule
+	p.Severity = c.Rules.Severity(rule)
+	p.Value = value
+	p.Message = msg
+	if p.Path != "" {
+		p.Path += where
+	} else {
+		p.Field = where
+	}
+	c.Report.Problems = append(c.Report.Problems, p)
+}
+
+// Errors returns the number of problems with severity error.
+func (r Report) Errors() int {
+	var n int
+	for _, p := range r.Problems {
+		if p.Severity == Error {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteJSON writes the report as a single JSON document.
+func (r Report) WriteJSON(w io.Writer) error {
+	if r.Problems == nil {
+		r.Problems = []Problem{}
+	}
+	return json.NewEncoder(w).Encode(r)
+}
+
+// WriteTSV writes one problem per line, preceded by a header.
+func (r Report) WriteTSV(w io.Writer) error {
+	if _, err := io.WriteString(w, "line\tcolumn\toffset\tpath\tfield\tseverity\trule\tvalue\tmessage\n"); err != nil {
+		return err
+	}
+	for _, p := range r.Problems {
+		if _, err := fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", p.Line, p.Column,
+			p.Offset, p.Path, p.Field, p.Severity, p.Rule, p.Value, p.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteText writes a human readable report with a summary line.
+func (r Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, p := range r.Problems {
+		msg := p.Message
+		if p.Value != "" {
+			msg = fmt.Sprintf("%s: %q", msg, p.Value)
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.Location(), p.Severity, p.Rule, msg); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d record(s), %d error(s), %d warning(s)\n",
+		r.Records, r.Errors(), len(r.Problems)-r.Errors())
+	return err
+}
+
+// Write writes the report in one of the formats text, json or tsv.
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		return r.WriteText(w)
+	case "json":
+		return r.WriteJSON(w)
+	case "tsv":
+		return r.WriteTSV(w)
+	default:
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+}
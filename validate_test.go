@@ -0,0 +1,22 @@
+package holdings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollector(t *testing.T) {
+	rules := Catalog{{Name: "issn", Severity: Error}}
+	v := Collector{Rules: rules}
+	v.At = Problem{Line: 2}
+	v.Add("issn", "eissn", "1234-5678", "invalid ISSN")
+	v.At = Problem{Offset: 10, Path: "/holdings/holding[1]"}
+	v.Add("other", "/issn[1]", "", "unknown")
+	want := []Problem{
+		{Rule: "issn", Severity: Error, Line: 2, Field: "eissn", Value: "1234-5678", Message: "invalid ISSN"},
+		{Rule: "other", Severity: Warning, Offset: 10, Path: "/holdings/holding[1]/issn[1]", Message: "unknown"},
+	}
+	if !reflect.DeepEqual(v.Report.Problems, want) {
+		t.Errorf("Add: got %+v, want %+v", v.Report.Problems, want)
+	}
+}